@@ -0,0 +1,223 @@
+// Copyright © 2021-2022 Platina Systems, Inc. All rights reserved.
+// Use of this source code is governed by the GPL-2 license described in the
+// LICENSE file.
+
+package opt
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// Auditor records each committed change, e.g. to a compliance log. Audit is
+// called in Seq order while holding the lock that serializes writers, so it
+// must not store options and should return without waiting on I/O.
+type Auditor interface {
+	Audit(Change)
+}
+
+// AuditLog is an Auditor that writes a JSON line for each option changed.
+// Audit only queues the lines for a goroutine to write so that a slow disk
+// or pipe doesn't stall stores; Close waits for those queued.
+type AuditLog struct {
+	w      io.Writer
+	mutex  sync.Mutex
+	queue  []byte
+	err    error
+	closed bool
+	wake   chan struct{}
+	exited chan struct{}
+}
+
+// auditRecord is the JSON line of an AuditLog.
+type auditRecord struct {
+	Seq    uint64
+	Time   time.Time
+	Name   string
+	Old    string
+	New    string
+	Source Source
+}
+
+// history is the bounded list of an option's most recent changes.
+type history struct {
+	n  int
+	cs []Change
+}
+
+// auditor and histories are guarded by the mutex that serializes writers.
+var (
+	auditor   Auditor
+	histories = make(map[Option]*history)
+)
+
+func NewAuditLog(w io.Writer) *AuditLog {
+	log := &AuditLog{
+		w:      w,
+		wake:   make(chan struct{}, 1),
+		exited: make(chan struct{}),
+	}
+	go log.run()
+	return log
+}
+
+// OpenAuditLog appends to the named file, creating it if necessary.
+func OpenAuditLog(name string) (*AuditLog, error) {
+	f, err := os.OpenFile(name, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, err
+	}
+	return NewAuditLog(f), nil
+}
+
+// Audit queues the change, or each of its Group, unless the log is closed
+// or a previous write failed.
+func (log *AuditLog) Audit(c Change) {
+	log.mutex.Lock()
+	defer log.mutex.Unlock()
+	if log.closed || log.err != nil {
+		return
+	}
+	buf := bytes.NewBuffer(log.queue)
+	enc := json.NewEncoder(buf)
+	for _, c := range c.Changes() {
+		if log.err = enc.Encode(auditRecord{
+			Seq:    c.Seq,
+			Time:   c.Time,
+			Name:   c.Name,
+			Old:    c.OldText(),
+			New:    c.NewText(),
+			Source: c.Source,
+		}); log.err != nil {
+			return
+		}
+	}
+	log.queue = buf.Bytes()
+	select {
+	case log.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Close the log once its queue is written, then its writer, if it's an
+// io.Closer, and return the first error of any write.
+func (log *AuditLog) Close() error {
+	log.mutex.Lock()
+	if !log.closed {
+		log.closed = true
+		close(log.wake)
+	}
+	log.mutex.Unlock()
+	<-log.exited
+	if closer, ok := log.w.(io.Closer); ok {
+		if err := closer.Close(); err != nil {
+			log.fail(err)
+		}
+	}
+	return log.Err()
+}
+
+// Err returns the first error of any write so far; once a write fails, the
+// log stops, so check Err to notice that before Close.
+func (log *AuditLog) Err() error {
+	log.mutex.Lock()
+	defer log.mutex.Unlock()
+	return log.err
+}
+
+func (log *AuditLog) fail(err error) {
+	log.mutex.Lock()
+	defer log.mutex.Unlock()
+	if log.err == nil {
+		log.err = err
+	}
+}
+
+// run writes the queue whenever woken until the log is closed.
+func (log *AuditLog) run() {
+	defer close(log.exited)
+	for range log.wake {
+		log.write()
+	}
+	log.write()
+}
+
+func (log *AuditLog) write() {
+	log.mutex.Lock()
+	b, err := log.queue, log.err
+	log.queue = nil
+	log.mutex.Unlock()
+	if len(b) == 0 || err != nil {
+		return
+	}
+	if _, err := log.w.Write(b); err != nil {
+		log.fail(err)
+	}
+}
+
+// History returns up to the last n changes of an option kept with
+// KeepHistory, oldest first.
+func History(opt Option) []Change {
+	if _, ok := opt.(stager); !ok {
+		return nil
+	}
+	mutex.Lock()
+	defer mutex.Unlock()
+	if h := histories[opt]; h != nil {
+		return append([]Change(nil), h.cs...)
+	}
+	return nil
+}
+
+// KeepHistory of the last n changes of opt, or if n is 0, stop keeping it.
+// Only the package's option types have changes, so others are ignored.
+func KeepHistory(opt Option, n int) {
+	if _, ok := opt.(stager); !ok {
+		return
+	}
+	mutex.Lock()
+	defer mutex.Unlock()
+	if n <= 0 {
+		delete(histories, opt)
+		return
+	}
+	h := histories[opt]
+	if h == nil {
+		h = new(history)
+		histories[opt] = h
+	}
+	h.n = n
+	if len(h.cs) > n {
+		h.cs = append([]Change(nil), h.cs[len(h.cs)-n:]...)
+	}
+}
+
+// SetAuditor to record every change, or with nil, stop.
+func SetAuditor(a Auditor) {
+	mutex.Lock()
+	defer mutex.Unlock()
+	auditor = a
+}
+
+// audit the event, while holding the write lock, and append each of its
+// changes to the history of the option.
+func audit(c Change) {
+	if auditor != nil {
+		auditor.Audit(c)
+	}
+	if len(histories) == 0 {
+		return
+	}
+	for _, c := range c.Changes() {
+		if h := histories[c.Opt]; h != nil {
+			if len(h.cs) == h.n {
+				h.cs = h.cs[1:]
+			}
+			h.cs = append(h.cs, c)
+		}
+	}
+}
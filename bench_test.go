@@ -0,0 +1,54 @@
+// Copyright © 2021-2022 Platina Systems, Inc. All rights reserved.
+// Use of this source code is governed by the GPL-2 license described in the
+// LICENSE file.
+
+package opt
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func BenchmarkValue(b *testing.B) {
+	n := NewNumber[uint64](1)
+	for _, parallelism := range []int{1, 4, 16, 64} {
+		b.Run(fmt.Sprint(parallelism), func(b *testing.B) {
+			b.SetParallelism(parallelism)
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					_ = n.Value()
+				}
+			})
+		})
+	}
+}
+
+// BenchmarkValueWithStores reads one option while another is continuously
+// stored.
+func BenchmarkValueWithStores(b *testing.B) {
+	n := NewNumber[uint64](1)
+	d := NewDuration(time.Second)
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		for i := time.Duration(0); ; i++ {
+			select {
+			case <-done:
+				return
+			default:
+				d.Store(i)
+			}
+		}
+	}()
+	for _, parallelism := range []int{1, 4, 16, 64} {
+		b.Run(fmt.Sprint(parallelism), func(b *testing.B) {
+			b.SetParallelism(parallelism)
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					_ = n.Value()
+				}
+			})
+		})
+	}
+}
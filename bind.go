@@ -0,0 +1,147 @@
+// Copyright © 2021-2022 Platina Systems, Inc. All rights reserved.
+// Use of this source code is governed by the GPL-2 license described in the
+// LICENSE file.
+
+package opt
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"unicode"
+)
+
+// Bind registers every option of the struct pointed to by v, including
+// those within nested structs and slices of structs. Each is named by the
+// dotted path of its toml, yaml or json field tags, or else the snake case
+// of the field names; slice elements are named by index, e.g.
+// "slice.structs.0.number". Fields tagged "-" and anonymous structs are
+// skipped and flattened like encoding/json.
+func (reg *Registry) Bind(v any) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("%T invalid", v)
+	}
+	return reg.bind("", rv.Elem(), nil)
+}
+
+func (reg *Registry) bind(path string, rv reflect.Value, attrs map[string]string) error {
+	if rv.Kind() == reflect.Pointer {
+		if rv.IsNil() {
+			return nil
+		}
+		rv = rv.Elem()
+	}
+	if rv.CanAddr() {
+		if opt, ok := rv.Addr().Interface().(Option); ok {
+			if s, found := attrs["default"]; found {
+				src := Source{Layer: Default, Name: "tag"}
+				if err := apply(opt, src, s); err != nil {
+					return fmt.Errorf("%s: %w", path, err)
+				}
+			}
+			return reg.register(path, &entry{
+				opt:    opt,
+				env:    attrs["env"],
+				flag:   attrs["name"],
+				usage:  attrs["usage"],
+				expand: attrs["expand"] == "true",
+			})
+		}
+	}
+	switch rv.Kind() {
+	case reflect.Struct:
+		rt := rv.Type()
+		for i := 0; i < rt.NumField(); i++ {
+			f := rt.Field(i)
+			if !f.IsExported() {
+				continue
+			}
+			name, ok := fieldname(f)
+			if !ok || f.Tag.Get("opt") == "-" {
+				continue
+			}
+			sub := path
+			if !f.Anonymous || len(name) > 0 ||
+				reflect.PointerTo(f.Type).Implements(optionType) {
+				if len(name) == 0 {
+					name = snake(f.Name)
+				}
+				sub = join(path, name)
+			}
+			err := reg.bind(sub, rv.Field(i), attributes(f.Tag.Get("opt")))
+			if err != nil {
+				return err
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < rv.Len(); i++ {
+			err := reg.bind(join(path, fmt.Sprint(i)), rv.Index(i), nil)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// attributes parses `opt:"name=listen,usage=listen address"`; a segment
+// without "=" continues the previous value.
+func attributes(tag string) map[string]string {
+	if len(tag) == 0 {
+		return nil
+	}
+	attrs := make(map[string]string)
+	var key string
+	for _, s := range strings.Split(tag, ",") {
+		if k, v, found := strings.Cut(s, "="); found {
+			key = strings.TrimSpace(k)
+			attrs[key] = v
+		} else if len(key) > 0 {
+			attrs[key] += "," + s
+		}
+	}
+	return attrs
+}
+
+var optionType = reflect.TypeOf((*Option)(nil)).Elem()
+
+// fieldname returns the first toml, yaml or json tag name of the field and
+// false if it's tagged "-".
+func fieldname(f reflect.StructField) (string, bool) {
+	for _, key := range []string{"toml", "yaml", "json"} {
+		name, _, _ := strings.Cut(f.Tag.Get(key), ",")
+		if name == "-" {
+			return "", false
+		}
+		if len(name) > 0 {
+			return name, true
+		}
+	}
+	return "", true
+}
+
+func join(path, name string) string {
+	if len(path) == 0 {
+		return name
+	}
+	return path + "." + name
+}
+
+// snake converts a Go identifier like "AddrPort" or "HTTPServer" into
+// "addr_port" and "http_server".
+func snake(s string) string {
+	sb := new(strings.Builder)
+	rs := []rune(s)
+	for i, r := range rs {
+		if unicode.IsUpper(r) {
+			if i > 0 && (!unicode.IsUpper(rs[i-1]) ||
+				i+1 < len(rs) && unicode.IsLower(rs[i+1])) {
+				sb.WriteByte('_')
+			}
+			r = unicode.ToLower(r)
+		}
+		sb.WriteRune(r)
+	}
+	return sb.String()
+}
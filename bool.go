@@ -5,17 +5,31 @@
 package opt
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
-	"unsafe"
 )
 
-type Bool struct{ v bool }
+type Bool struct{ v value[bool] }
 
-func NewBool(v bool) *Bool { return &Bool{v} }
+func NewBool(v bool) *Bool { return &Bool{newValue(v)} }
+
+func (opt *Bool) box() *box { return opt.v.box() }
+
+func (opt *Bool) cell() *value[bool] { return &opt.v }
+
+func (opt *Bool) check(bool) error { return nil }
+
+func (opt *Bool) Default() bool {
+	return opt.v.state().layers[Default]
+}
 
 func (opt Bool) IsBoolFlag() bool { return true }
 
+func (opt *Bool) IsDefault() bool {
+	return opt.v.state().top == Default
+}
+
 func (opt Bool) MarshalJSON() ([]byte, error) {
 	return json.Marshal(opt.Value())
 }
@@ -28,24 +42,48 @@ func (opt Bool) MarshalYAML() (interface{}, error) {
 	return opt.Value(), nil
 }
 
-func (opt *Bool) Set(s string) error {
-	var v bool
+func (opt *Bool) parse(s string) (bool, error) {
+	v := true
 	if len(s) > 0 {
 		if _, err := fmt.Sscan(s, &v); err != nil {
-			return nil
+			return false, err
 		}
-	} else {
-		v = true
+	}
+	return v, nil
+}
+
+func (opt *Bool) reset() write { return reset[bool](opt) }
+
+func (opt *Bool) Reset() {
+	commit(opt.reset())
+}
+
+func (opt *Bool) schema() map[string]any {
+	return map[string]any{"type": "boolean"}
+}
+
+func (opt *Bool) Set(s string) error {
+	v, err := opt.parse(s)
+	if err != nil {
+		return err
 	}
 	return opt.Store(v)
 }
 
+func (opt *Bool) settings() []Setting {
+	return opt.v.state().settings(opt)
+}
+
+func (opt *Bool) Source() Source {
+	return opt.v.state().source()
+}
+
+func (opt *Bool) stage(src Source, v any) (write, error) {
+	return stage[bool](opt, src, v)
+}
+
 func (opt *Bool) Store(v bool) error {
-	mutex.Lock()
-	defer mutex.Unlock()
-	opt.v = v
-	publish(uintptr(unsafe.Pointer(opt)))
-	return nil
+	return store[bool](opt, v)
 }
 
 func (opt Bool) String() string {
@@ -64,8 +102,19 @@ func (opt *Bool) UnmarshalText(text []byte) error {
 	return opt.Set(string(text))
 }
 
+func (opt *Bool) Unset(l Layer) error {
+	return apply(opt, Source{Layer: l}, nil)
+}
+
+func (opt *Bool) Validate(fns ...func(bool) error) *Bool {
+	opt.v.validate(fns)
+	return opt
+}
+
 func (opt Bool) Value() bool {
-	mutex.RLock()
-	defer mutex.RUnlock()
-	return opt.v
+	return opt.v.load()
+}
+
+func (opt *Bool) Watch(ctx context.Context) <-chan bool {
+	return watch(ctx, opt, opt.Value)
 }
@@ -0,0 +1,12 @@
+// Copyright © 2021-2022 Platina Systems, Inc. All rights reserved.
+// Use of this source code is governed by the GPL-2 license described in the
+// LICENSE file.
+
+//go:build !(aix || darwin || dragonfly || freebsd || linux || netbsd || openbsd || solaris)
+
+package opt
+
+import "os"
+
+// chown isn't available, so saved files are owned by the process.
+func chown(*os.File, os.FileInfo) error { return nil }
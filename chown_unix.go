@@ -0,0 +1,29 @@
+// Copyright © 2021-2022 Platina Systems, Inc. All rights reserved.
+// Use of this source code is governed by the GPL-2 license described in the
+// LICENSE file.
+
+//go:build aix || darwin || dragonfly || freebsd || linux || netbsd || openbsd || solaris
+
+package opt
+
+import (
+	"errors"
+	"os"
+	"syscall"
+)
+
+// chown f to the owner and group of the file it replaces, or if that's not
+// permitted, to just the group.
+func chown(f *os.File, fi os.FileInfo) error {
+	st, ok := fi.Sys().(*syscall.Stat_t)
+	if !ok {
+		return nil
+	}
+	err := f.Chown(int(st.Uid), int(st.Gid))
+	if errors.Is(err, os.ErrPermission) {
+		if err = f.Chown(-1, int(st.Gid)); errors.Is(err, os.ErrPermission) {
+			err = nil
+		}
+	}
+	return err
+}
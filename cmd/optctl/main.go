@@ -0,0 +1,105 @@
+// Copyright © 2021-2022 Platina Systems, Inc. All rights reserved.
+// Use of this source code is governed by the GPL-2 license described in the
+// LICENSE file.
+
+// Optctl gets, sets, lists and watches the options of a running process
+// through the Unix socket served by its opt.Registry, like sysctl.
+//
+//	optctl [-s SOCKET] [NAME...]            print NAME = VALUE, or all
+//	optctl [-s SOCKET] NAME=VALUE...        set each option
+//	optctl [-s SOCKET] -u NAME...           unset each option's Runtime layer
+//	optctl [-s SOCKET] -l [NAME...]         list option names
+//	optctl [-s SOCKET] -w [NAME...]         print each change
+//
+// The socket is $OPTCTL_SOCKET if not given.
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+)
+
+func main() {
+	socket := flag.String("s", os.Getenv("OPTCTL_SOCKET"), "socket `path`")
+	list := flag.Bool("l", false, "list option names")
+	unset := flag.Bool("u", false, "unset the runtime value of each option")
+	watch := flag.Bool("w", false, "print each change")
+	flag.Parse()
+	if err := run(*socket, *list, *unset, *watch, flag.Args()); err != nil {
+		fmt.Fprintln(os.Stderr, "optctl:", err)
+		os.Exit(1)
+	}
+}
+
+func run(socket string, list, unset, watch bool, args []string) error {
+	if len(socket) == 0 {
+		return errors.New("no socket")
+	}
+	conn, err := net.Dial("unix", socket)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	r := bufio.NewScanner(conn)
+	var reqs []string
+	switch {
+	case list:
+		reqs = []string{strings.Join(append([]string{"list"}, args...), " ")}
+	case watch:
+		reqs = []string{strings.Join(append([]string{"watch"}, args...), " ")}
+	case unset:
+		for _, name := range args {
+			reqs = append(reqs, "unset "+name)
+		}
+	case len(args) == 0:
+		reqs = []string{"get"}
+	default:
+		for _, arg := range args {
+			if name, s, found := strings.Cut(arg, "="); found {
+				reqs = append(reqs, "set "+name+" "+strconv.Quote(s))
+			} else {
+				reqs = append(reqs, "get "+arg)
+			}
+		}
+	}
+	for _, req := range reqs {
+		if _, err := fmt.Fprintln(conn, req); err != nil {
+			return err
+		}
+		if err := reply(r, os.Stdout); err != nil {
+			return err
+		}
+	}
+	if watch {
+		for r.Scan() {
+			fmt.Println(r.Text())
+		}
+		return r.Err()
+	}
+	return nil
+}
+
+// reply copies response lines to w until "ok" or "error MESSAGE".
+func reply(r *bufio.Scanner, w io.Writer) error {
+	for r.Scan() {
+		s := r.Text()
+		if s == "ok" {
+			return nil
+		}
+		if strings.HasPrefix(s, "error ") {
+			return errors.New(strings.TrimPrefix(s, "error "))
+		}
+		fmt.Fprintln(w, s)
+	}
+	if err := r.Err(); err != nil {
+		return err
+	}
+	return io.ErrUnexpectedEOF
+}
@@ -0,0 +1,139 @@
+// Copyright © 2021-2022 Platina Systems, Inc. All rights reserved.
+// Use of this source code is governed by the GPL-2 license described in the
+// LICENSE file.
+
+package opt
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+)
+
+// Serve the control protocol of cmd/optctl on each connection accepted from
+// l, e.g. a Unix socket, until Accept fails. Each request is a line:
+//
+//	get [NAME...]       NAME = VALUE of the options equal to or within each
+//	                    path, or all of them
+//	list [NAME...]      names of those options
+//	set NAME VALUE      set the Runtime layer of the option from the rest of
+//	                    the line, unquoted if it's a Go string literal
+//	unset NAME          unset the Runtime layer of the option
+//	watch [NAME...]     NAME = VALUE of those options after each change
+//
+// and is answered by its lines, then "ok" or "error MESSAGE". After its
+// "ok", watch streams changes until the client closes the connection.
+// Values with line breaks are quoted. Stores are sourced by "socket".
+func (reg *Registry) Serve(l net.Listener) error {
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			return err
+		}
+		go reg.serve(conn)
+	}
+}
+
+func (reg *Registry) serve(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewScanner(conn)
+	w := bufio.NewWriter(conn)
+	src := Source{Layer: Runtime, Name: "socket"}
+	for r.Scan() {
+		cmd, args, _ := strings.Cut(strings.TrimSpace(r.Text()), " ")
+		args = strings.TrimSpace(args)
+		var err error
+		switch cmd {
+		case "":
+			continue
+		case "get", "list", "watch":
+			var names []string
+			if names, err = reg.within(strings.Fields(args)); err != nil {
+				break
+			}
+			if cmd == "watch" {
+				fmt.Fprintln(w, "ok")
+				reg.watch(r, w, names)
+				return
+			}
+			for _, name := range names {
+				if cmd == "list" {
+					fmt.Fprintln(w, name)
+				} else if opt := reg.Lookup(name); opt != nil {
+					line(w, name, textof(opt))
+				}
+			}
+		case "set":
+			name, s, _ := strings.Cut(args, " ")
+			s = strings.TrimSpace(s)
+			if u, uerr := strconv.Unquote(s); uerr == nil {
+				s = u
+			}
+			err = reg.SetSource(name, src, s)
+		case "unset":
+			err = reg.Unset(args, Runtime)
+		default:
+			err = fmt.Errorf("%q unknown", cmd)
+		}
+		if err != nil {
+			fmt.Fprintln(w, "error", strings.ReplaceAll(err.Error(), "\n", " "))
+		} else {
+			fmt.Fprintln(w, "ok")
+		}
+		if w.Flush() != nil {
+			return
+		}
+	}
+}
+
+// watch writes the names and new values of changes to the named options
+// until reading or writing the connection fails.
+func (reg *Registry) watch(r *bufio.Scanner, w *bufio.Writer, names []string) {
+	watched := make(map[Option]string, len(names))
+	for _, name := range names {
+		if opt := reg.Lookup(name); opt != nil {
+			watched[opt] = name
+		}
+	}
+	changes := make(chan Change)
+	SubscribePolicy(changes, Coalesce)
+	defer Unsubscribe(changes)
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+		for r.Scan() {
+		}
+	}()
+	for w.Flush() == nil {
+		select {
+		case c := <-changes:
+			for _, c := range c.Changes() {
+				if name, found := watched[c.Opt]; found {
+					line(w, name, c.NewText())
+				}
+			}
+		case <-closed:
+			return
+		}
+	}
+}
+
+// within returns the registered names equal to or within each of the paths,
+// or all of them.
+func (reg *Registry) within(paths []string) ([]string, error) {
+	names := reg.List(paths...)
+	if len(paths) > 0 && len(names) == 0 {
+		return nil, fmt.Errorf("%q %w", strings.Join(paths, " "), ErrNotFound)
+	}
+	return names, nil
+}
+
+func line(w io.Writer, name, s string) {
+	if strings.ContainsAny(s, "\r\n") {
+		s = strconv.Quote(s)
+	}
+	fmt.Fprintln(w, name, "=", s)
+}
@@ -0,0 +1,200 @@
+// Copyright © 2021-2022 Platina Systems, Inc. All rights reserved.
+// Use of this source code is governed by the GPL-2 license described in the
+// LICENSE file.
+
+package opt
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Diff lists the differences between two configurations in name order.
+// Marshal it with encoding/json for its JSON form.
+type Diff []Difference
+
+// Difference of a path's text value from the old to new configuration; Old
+// is empty if Added and New if Removed.
+type Difference struct {
+	Edit Edit
+	Name string
+	Old  string
+	New  string
+}
+
+// Edit of a path from the old to new configuration.
+type Edit uint8
+
+const (
+	Added Edit = iota
+	Removed
+	Changed
+)
+
+var edits = []string{
+	Added:   "added",
+	Removed: "removed",
+	Changed: "changed",
+}
+
+// Compare configurations of text values by path name, e.g. those of
+// Registry.Texts and ReadFile.
+func Compare(old, new map[string]string) Diff {
+	var diff Diff
+	for name, s := range old {
+		if t, found := new[name]; !found {
+			diff = append(diff, Difference{Removed, name, s, ""})
+		} else if s != t {
+			diff = append(diff, Difference{Changed, name, s, t})
+		}
+	}
+	for name, t := range new {
+		if _, found := old[name]; !found {
+			diff = append(diff, Difference{Added, name, "", t})
+		}
+	}
+	sort.Slice(diff, func(i, j int) bool {
+		return diff[i].Name < diff[j].Name
+	})
+	return diff
+}
+
+// ReadFile returns the text value of each path name in a TOML, YAML or JSON
+// file, by its extension.
+func ReadFile(path string) (map[string]string, error) {
+	texts, err := readFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return texts, nil
+}
+
+// Diff compares the values of the registered options with those they'd
+// have if Load replaced the given layer with text values, e.g. those of
+// ReadFile: options missing from texts lose that layer, and values hidden by
+// a higher layer don't change. Each text is formatted like the String
+// method of its option so that "1m" and "1m0s" are the same Duration.
+// Unregistered names, which Load rejects, are Added.
+func (reg *Registry) Diff(l Layer, texts map[string]string) Diff {
+	before := reg.Texts()
+	after := make(map[string]string, len(before))
+	for name, s := range texts {
+		if reg.Lookup(name) == nil {
+			after[name] = s
+		}
+	}
+	reg.Visit(func(name string, opt Option) error {
+		after[name] = before[name]
+		st, ok := opt.(stager)
+		if !ok {
+			return nil
+		}
+		top := Default
+		for _, setting := range st.settings() {
+			if setting.Source.Layer != l || l == Default {
+				top = setting.Source.Layer
+				after[name] = setting.Value
+			}
+		}
+		if s, found := texts[name]; found && l >= top {
+			after[name] = format(opt, s)
+		}
+		return nil
+	})
+	return Compare(before, after)
+}
+
+// Texts returns the text value of each registered option by name.
+func (reg *Registry) Texts() map[string]string {
+	texts := make(map[string]string)
+	reg.Visit(func(name string, opt Option) error {
+		texts[name] = textof(opt)
+		return nil
+	})
+	return texts
+}
+
+func (d Diff) String() string {
+	sb := new(strings.Builder)
+	for _, x := range d {
+		fmt.Fprintln(sb, x)
+	}
+	return sb.String()
+}
+
+// Unified formats the differences like diff -u of files with a line for
+// each path, "name = value", labeled by the given names of the old and new
+// configurations.
+func (d Diff) Unified(from, to string) string {
+	if len(d) == 0 {
+		return ""
+	}
+	sb := new(strings.Builder)
+	fmt.Fprintln(sb, "---", from)
+	fmt.Fprintln(sb, "+++", to)
+	for _, x := range d {
+		if x.Edit != Added {
+			sb.WriteByte('-')
+			line(sb, x.Name, x.Old)
+		}
+		if x.Edit != Removed {
+			sb.WriteByte('+')
+			line(sb, x.Name, x.New)
+		}
+	}
+	return sb.String()
+}
+
+func (x Difference) String() string {
+	switch x.Edit {
+	case Added:
+		return fmt.Sprintf("%v %s = %s", x.Edit, x.Name, x.New)
+	case Removed:
+		return fmt.Sprintf("%v %s = %s", x.Edit, x.Name, x.Old)
+	}
+	return fmt.Sprintf("%v %s: %s -> %s", x.Edit, x.Name, x.Old, x.New)
+}
+
+func (e Edit) MarshalText() ([]byte, error) {
+	return []byte(e.String()), nil
+}
+
+func (e Edit) String() string {
+	if int(e) < len(edits) {
+		return edits[e]
+	}
+	return fmt.Sprintf("edit(%d)", e)
+}
+
+func (e *Edit) UnmarshalText(text []byte) error {
+	for i, s := range edits {
+		if s == string(text) {
+			*e = Edit(i)
+			return nil
+		}
+	}
+	return fmt.Errorf("%q invalid", text)
+}
+
+// format v, typed or its text form, like the String method of opt by storing
+// it in a new, unshared option of the same type, or if that fails, return the
+// text of v.
+func format(opt Option, v any) string {
+	t := reflect.TypeOf(opt)
+	if t.Kind() != reflect.Pointer {
+		return text(v)
+	}
+	scratch, ok := reflect.New(t.Elem()).Interface().(stager)
+	if !ok {
+		return text(v)
+	}
+	w, err := scratch.stage(Source{Layer: Runtime}, v)
+	if err != nil {
+		return text(v)
+	}
+	w.swap(time.Time{})
+	return textof(scratch)
+}
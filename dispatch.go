@@ -0,0 +1,166 @@
+// Copyright © 2021-2022 Platina Systems, Inc. All rights reserved.
+// Use of this source code is governed by the GPL-2 license described in the
+// LICENSE file.
+
+package opt
+
+import "sync"
+
+// Policy of a subscriber's queue of changes waiting for it to receive.
+type Policy uint8
+
+const (
+	// Unbounded queues every change until it's received, so the memory
+	// of a stalled receiver's queue grows without limit. Writers never
+	// wait on a receiver with any policy.
+	Unbounded Policy = iota
+	// DropOldest discards the oldest queued change to keep at most
+	// cap(ch), or one, waiting.
+	DropOldest
+	// Coalesce merges the queued changes of each option into one from its
+	// earliest old value to its latest new value; Tx groups aren't merged.
+	Coalesce
+)
+
+var (
+	submutex sync.Mutex
+	subs     []*subscriber
+)
+
+// subscriber has a goroutine that delivers its queue of changes so that
+// publish never waits on a receiver.
+type subscriber struct {
+	ch     chan<- Change
+	policy Policy
+	opt    Option // if non-nil, only changes of this option
+	mutex  sync.Mutex
+	queue  []Change
+	wake   chan struct{}
+	done   chan struct{}
+	exited chan struct{}
+}
+
+// Subscribe to option change notifications with the Unbounded policy;
+// use SubscribePolicy with DropOldest or Coalesce if the receiver may stall.
+func Subscribe(ch chan<- Change) {
+	SubscribePolicy(ch, Unbounded)
+}
+
+// SubscribePolicy subscribes to option change notifications that are queued
+// by the given policy when the receiver falls behind.
+func SubscribePolicy(ch chan<- Change, policy Policy) {
+	subscribe(ch, policy, nil)
+}
+
+func subscribe(ch chan<- Change, policy Policy, opt Option) {
+	sub := &subscriber{
+		ch:     ch,
+		policy: policy,
+		opt:    opt,
+		wake:   make(chan struct{}, 1),
+		done:   make(chan struct{}),
+		exited: make(chan struct{}),
+	}
+	submutex.Lock()
+	defer submutex.Unlock()
+	subs = append(subs, sub)
+	go sub.run()
+}
+
+// Unsubscribe to option change notifications; any queued changes are
+// discarded and nothing is sent on ch after return, so it may be closed.
+func Unsubscribe(ch chan<- Change) {
+	submutex.Lock()
+	var sub *subscriber
+	for i, p := range subs {
+		if ch == p.ch {
+			sub = p
+			copy(subs[i:], subs[i+1:])
+			subs = subs[:len(subs)-1]
+			break
+		}
+	}
+	submutex.Unlock()
+	if sub != nil {
+		close(sub.done)
+		<-sub.exited
+	}
+}
+
+func dispatch(c Change) {
+	submutex.Lock()
+	defer submutex.Unlock()
+	for _, sub := range subs {
+		sub.push(c)
+	}
+}
+
+func (sub *subscriber) push(c Change) {
+	if sub.opt != nil {
+		var found bool
+		for _, g := range c.Changes() {
+			if found = g.Opt == sub.opt; found {
+				c = g
+				break
+			}
+		}
+		if !found {
+			return
+		}
+	}
+	sub.mutex.Lock()
+	defer sub.mutex.Unlock()
+	switch sub.policy {
+	case DropOldest:
+		if depth := cap(sub.ch); len(sub.queue) >= depth && len(sub.queue) > 0 {
+			copy(sub.queue, sub.queue[1:])
+			sub.queue = sub.queue[:len(sub.queue)-1]
+		}
+	case Coalesce:
+		for i := range sub.queue {
+			if c.Opt != nil && sub.queue[i].Opt == c.Opt {
+				c.Old = sub.queue[i].Old
+				copy(sub.queue[i:], sub.queue[i+1:])
+				sub.queue = sub.queue[:len(sub.queue)-1]
+				break
+			}
+		}
+	}
+	sub.queue = append(sub.queue, c)
+	select {
+	case sub.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (sub *subscriber) pop() (Change, bool) {
+	sub.mutex.Lock()
+	defer sub.mutex.Unlock()
+	if len(sub.queue) == 0 {
+		return Change{}, false
+	}
+	c := sub.queue[0]
+	sub.queue[0] = Change{}
+	sub.queue = sub.queue[1:]
+	return c, true
+}
+
+func (sub *subscriber) run() {
+	defer close(sub.exited)
+	for {
+		c, ok := sub.pop()
+		if !ok {
+			select {
+			case <-sub.wake:
+				continue
+			case <-sub.done:
+				return
+			}
+		}
+		select {
+		case sub.ch <- c:
+		case <-sub.done:
+			return
+		}
+	}
+}
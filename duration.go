@@ -5,16 +5,19 @@
 package opt
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"time"
-	"unsafe"
 )
 
-type Duration struct{ v, min, max time.Duration }
+type Duration struct {
+	v        value[time.Duration]
+	min, max time.Duration
+}
 
 func LimitedDuration(v, min, max time.Duration) *Duration {
-	return &Duration{v, min, max}
+	return &Duration{newValue(v), min, max}
 }
 
 func MustParseDuration(s string) *Duration {
@@ -22,11 +25,35 @@ func MustParseDuration(s string) *Duration {
 	if err != nil {
 		panic(err)
 	}
-	return &Duration{v: v}
+	return &Duration{v: newValue(v)}
 }
 
 func NewDuration(v time.Duration) *Duration {
-	return &Duration{v: v}
+	return &Duration{v: newValue(v)}
+}
+
+func (opt *Duration) box() *box { return opt.v.box() }
+
+func (opt *Duration) cell() *value[time.Duration] { return &opt.v }
+
+func (opt *Duration) check(v time.Duration) error {
+	if opt.min != opt.max {
+		if v < opt.min {
+			return fmt.Errorf("%v < min{%v}", v, opt.min)
+		}
+		if v > opt.max {
+			return fmt.Errorf("%v > max{%v}", v, opt.max)
+		}
+	}
+	return nil
+}
+
+func (opt *Duration) Default() time.Duration {
+	return opt.v.state().layers[Default]
+}
+
+func (opt *Duration) IsDefault() bool {
+	return opt.v.state().top == Default
 }
 
 func (opt Duration) MarshalJSON() ([]byte, error) {
@@ -41,28 +68,42 @@ func (opt Duration) MarshalYAML() (interface{}, error) {
 	return opt.String(), nil
 }
 
+func (opt *Duration) parse(s string) (time.Duration, error) {
+	return time.ParseDuration(s)
+}
+
+func (opt *Duration) reset() write { return reset[time.Duration](opt) }
+
+func (opt *Duration) Reset() {
+	commit(opt.reset())
+}
+
+func (opt *Duration) schema() map[string]any {
+	return map[string]any{"type": "string", "pattern": durationPattern}
+}
+
 func (opt *Duration) Set(s string) error {
-	v, err := time.ParseDuration(s)
+	v, err := opt.parse(s)
 	if err != nil {
 		return err
 	}
 	return opt.Store(v)
 }
 
+func (opt *Duration) settings() []Setting {
+	return opt.v.state().settings(opt)
+}
+
+func (opt *Duration) Source() Source {
+	return opt.v.state().source()
+}
+
+func (opt *Duration) stage(src Source, v any) (write, error) {
+	return stage[time.Duration](opt, src, v)
+}
+
 func (opt *Duration) Store(v time.Duration) error {
-	if opt.min != opt.max {
-		if v < opt.min {
-			return fmt.Errorf("%v < min{%v}", v, opt.min)
-		}
-		if v > opt.max {
-			return fmt.Errorf("%v > max{%v}", v, opt.max)
-		}
-	}
-	mutex.Lock()
-	defer mutex.Unlock()
-	opt.v = v
-	publish(uintptr(unsafe.Pointer(opt)))
-	return nil
+	return store[time.Duration](opt, v)
 }
 
 func (opt Duration) String() string {
@@ -81,8 +122,19 @@ func (opt *Duration) UnmarshalText(text []byte) error {
 	return opt.Set(string(text))
 }
 
+func (opt *Duration) Unset(l Layer) error {
+	return apply(opt, Source{Layer: l}, nil)
+}
+
+func (opt *Duration) Validate(fns ...func(time.Duration) error) *Duration {
+	opt.v.validate(fns)
+	return opt
+}
+
 func (opt Duration) Value() time.Duration {
-	mutex.RLock()
-	defer mutex.RUnlock()
-	return opt.v
+	return opt.v.load()
+}
+
+func (opt *Duration) Watch(ctx context.Context) <-chan time.Duration {
+	return watch(ctx, opt, opt.Value)
 }
@@ -5,18 +5,76 @@
 package opt
 
 import (
+	"fmt"
 	"os"
+	"sort"
 	"strings"
+	"unicode"
 )
 
 // Associate environment variable names with Opt[T].Set()
 type Env map[string]func(string) error
 
-// Set options from os.Environ() or given list of KEY=VALUEs.
+// NewEnv associates each registered option with an environment variable
+// named by the SCREAMING_SNAKE case of the given prefix and option path,
+// e.g. "MYAPP" and "scalar.addr_port" become "MYAPP_SCALAR_ADDR_PORT". An
+// `opt:"env=NAME"` field tag names the variable instead of its path. The
+// resulting Env sets the Environment layer of each option.
+func NewEnv(prefix string, reg *Registry) Env {
+	env := make(Env)
+	for _, name := range reg.List() {
+		e := reg.entry(name)
+		if e == nil || e.env == "-" {
+			continue
+		}
+		path := name
+		if len(e.env) > 0 {
+			name = e.env
+		}
+		opt := e.opt
+		src := Source{Layer: Environment, Name: envname(prefix, name)}
+		env[src.Name] = func(s string) error {
+			s, err := reg.text(path, s, nil)
+			if err != nil {
+				return err
+			}
+			return apply(opt, src, s)
+		}
+	}
+	return env
+}
+
+// Names returns the sorted list of recognized variables.
+func (env Env) Names() []string {
+	names := make([]string, 0, len(env))
+	for name := range env {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Set options from os.Environ() or given list of KEY=VALUEs. An unrecognized
+// KEY_FILE=PATH sets KEY from the trimmed contents of the file, as used by
+// containers to deliver secrets; having both KEY and KEY_FILE is an error.
 func (env Env) Set(args ...string) error {
 	if len(args) == 0 {
 		args = os.Environ()
 	}
+	keys := make(map[string]bool, len(args))
+	for _, k := range args {
+		k, _, _ = strings.Cut(k, "=")
+		keys[k] = true
+	}
+	for k := range keys {
+		key := strings.TrimSuffix(k, "_FILE")
+		if _, ok := env[k]; ok || key == k || !keys[key] {
+			continue
+		}
+		if _, ok := env[key]; ok {
+			return fmt.Errorf("%s and %s duplicate", key, k)
+		}
+	}
 	for _, k := range args {
 		if len(k) == 0 {
 			continue
@@ -30,7 +88,28 @@ func (env Env) Set(args ...string) error {
 			if err := set(v); err != nil {
 				return err
 			}
+		} else if set, ok := env[strings.TrimSuffix(k, "_FILE")]; ok &&
+			strings.HasSuffix(k, "_FILE") {
+			b, err := os.ReadFile(v)
+			if err != nil {
+				return fmt.Errorf("%s: %w", k, err)
+			}
+			if err = set(strings.TrimSpace(string(b))); err != nil {
+				return err
+			}
 		}
 	}
 	return nil
 }
+
+func envname(prefix, name string) string {
+	if len(prefix) > 0 {
+		name = prefix + "_" + name
+	}
+	return strings.Map(func(r rune) rune {
+		if r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)) {
+			return unicode.ToUpper(r)
+		}
+		return '_'
+	}, name)
+}
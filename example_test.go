@@ -5,12 +5,21 @@
 package opt
 
 import (
+	"bufio"
+	"context"
 	"encoding/json"
 	"flag"
 	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"net/url"
 	"os"
+	"path/filepath"
+	"strings"
 	"time"
-	"unsafe"
 
 	"github.com/BurntSushi/toml"
 	"gopkg.in/yaml.v2"
@@ -40,7 +49,7 @@ type StructExample struct {
 	}
 }
 
-func ExampleMustParse() {
+func Example_mustParse() {
 	fmt.Println(MustParseDuration("10s"))
 	fmt.Println(MustParseAddr("192.168.0.1"))
 	fmt.Println(MustParseAddrPort("192.168.0.1:80"))
@@ -85,6 +94,35 @@ func ExampleLimitedTime() {
 	// Output: too late
 }
 
+func ExampleNetIP_Validate() {
+	prefix := MustParsePrefix("10.0.0.0/16").Validate(
+		func(p netip.Prefix) error {
+			if !p.Addr().Is4() {
+				return fmt.Errorf("%v isn't IPv4", p)
+			}
+			if p.Bits() > 24 {
+				return fmt.Errorf("%v longer than /24", p)
+			}
+			return nil
+		})
+	u := MustParseURL("https://golang.org").Validate(
+		func(u url.URL) error {
+			if u.Scheme != "https" {
+				return fmt.Errorf("%s scheme isn't https", u.Scheme)
+			}
+			return nil
+		})
+	fmt.Println(prefix.Set("fe80::/64"))
+	fmt.Println(prefix.UnmarshalJSON([]byte(`"10.1.1.0/28"`)))
+	fmt.Println(prefix.Set("10.1.0.0/24"), prefix)
+	fmt.Println(u.Set("http://golang.org"), u)
+	// Output:
+	// fe80::/64 isn't IPv4
+	// 10.1.1.0/28 longer than /24
+	// <nil> 10.1.0.0/24
+	// http scheme isn't https https://golang.org
+}
+
 func ExampleEnv() {
 	var x StructExample
 	env := Env{
@@ -134,6 +172,44 @@ func ExampleEnv() {
 	// https://golang.org/pkg/flag
 }
 
+func ExampleNewEnv() {
+	var x StructExample
+	var reg Registry
+	if err := reg.Bind(&x); err != nil {
+		fmt.Println(err)
+		return
+	}
+	env := NewEnv("MYAPP", &reg)
+	err := env.Set(
+		"MYAPP_SCALAR_ADDR_PORT=10.1.1.1:80",
+		"MYAPP_SCALAR_DURATION=5m",
+		"OTHER_DURATION=1h",
+	)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Println(x.Scalar.AddrPort, x.Scalar.Duration)
+	for _, name := range env.Names() {
+		fmt.Println(name)
+	}
+	// Output:
+	// 10.1.1.1:80 5m0s
+	// MYAPP_SCALAR_ADDR
+	// MYAPP_SCALAR_ADDR_PORT
+	// MYAPP_SCALAR_BOOL
+	// MYAPP_SCALAR_DURATION
+	// MYAPP_SCALAR_FLOAT
+	// MYAPP_SCALAR_INT
+	// MYAPP_SCALAR_PREFIX
+	// MYAPP_SCALAR_STRING
+	// MYAPP_SCALAR_URL
+	// MYAPP_SLICE_FLOATS
+	// MYAPP_SLICE_INTS
+	// MYAPP_SLICE_PREFIXES
+	// MYAPP_SLICE_STRINGS
+}
+
 func ExampleFlag() {
 	var foobar struct {
 		foo, bar Bool
@@ -184,28 +260,985 @@ func ExampleFlag() {
 	// url: https://golang.org/pkg/flag
 }
 
+func ExampleRegistry() {
+	var reg Registry
+	reg.Register("scalar.addr_port", MustParseAddrPort("192.168.1.1:80"))
+	reg.Register("scalar.duration", MustParseDuration("3s"))
+	reg.Register("slice.strings", NewStrings([]string{"hello world"}))
+	if err := reg.Set("scalar.duration", "1m"); err != nil {
+		fmt.Println(err)
+	}
+	if err := reg.Set("slice.strings", `[hello, "bonjour le monde"]`); err != nil {
+		fmt.Println(err)
+	}
+	fmt.Println(reg.Set("scalar.url", "https://golang.org"))
+	for _, name := range reg.List("scalar", "slice") {
+		s, _ := reg.Get(name)
+		fmt.Printf("%s: %s\n", name, s)
+	}
+	ap := reg.Lookup("scalar.addr_port").(*AddrPort)
+	fmt.Println(ap.Value().Port())
+	fmt.Println(reg.Register("func", setter(nil)))
+	// Output:
+	// "scalar.url" not found
+	// scalar.addr_port: 192.168.1.1:80
+	// scalar.duration: 1m0s
+	// slice.strings: [hello "bonjour le monde"]
+	// 80
+	// func: opt.setter invalid
+}
+
+// setter is a flag.Value that isn't comparable.
+type setter func(string) error
+
+func (set setter) Set(s string) error { return set(s) }
+
+func (set setter) String() string { return "" }
+
+func ExampleRegistry_Unregister() {
+	var a, b Registry
+	timeout := NewDuration(time.Second)
+	a.Register("timeout", timeout)
+	fmt.Println(b.Register("link.timeout", timeout))
+	a.Unregister("timeout")
+	b.Register("link.timeout", timeout)
+	ch := make(chan Change)
+	Subscribe(ch)
+	defer Unsubscribe(ch)
+	timeout.Set("5s")
+	fmt.Println(<-ch)
+	fmt.Println(a.Unregister("timeout"))
+	// Output:
+	// link.timeout: *opt.Duration registered as "timeout"
+	// link.timeout: 1s -> 5s
+	// "timeout" not found
+}
+
+func ExampleRegistry_Bind() {
+	var x StructExample
+	var reg Registry
+	x.Slice.Structs = make([]struct {
+		Number Number[int]
+		String String[string]
+	}, 2)
+	if err := reg.Bind(&x); err != nil {
+		fmt.Println(err)
+		return
+	}
+	reg.Set("scalar.addr_port", "10.1.1.1:80")
+	reg.Set("slice.structs.1.string", "hello world")
+	fmt.Println(x.Scalar.AddrPort)
+	fmt.Println(x.Slice.Structs[1].String)
+	for _, name := range reg.List() {
+		fmt.Println(name)
+	}
+	// Output:
+	// 10.1.1.1:80
+	// hello world
+	// scalar.addr
+	// scalar.addr_port
+	// scalar.bool
+	// scalar.duration
+	// scalar.float
+	// scalar.int
+	// scalar.prefix
+	// scalar.string
+	// scalar.url
+	// slice.floats
+	// slice.ints
+	// slice.prefixes
+	// slice.strings
+	// slice.structs.0.number
+	// slice.structs.0.string
+	// slice.structs.1.number
+	// slice.structs.1.string
+}
+
+func ExampleRegistry_FlagSet() {
+	var x struct {
+		Listen  AddrPort       `opt:"name=listen,default=0.0.0.0:80,usage=listen address"`
+		Verbose Bool           `opt:"name=v,usage=verbose"`
+		Timeout Duration       `opt:"default=30s,usage=idle timeout, 0 for none"`
+		Secret  String[string] `opt:"-"`
+	}
+	var reg Registry
+	if err := reg.Bind(&x); err != nil {
+		fmt.Println(err)
+		return
+	}
+	fs, err := reg.FlagSet("example", flag.ContinueOnError)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	fs.SetOutput(os.Stdout)
+	fs.PrintDefaults()
+	err = fs.Parse([]string{"-v", "-listen", "127.0.0.1:80"})
+	if err != nil {
+		fmt.Println(err)
+	}
+	fmt.Println(x.Listen, x.Verbose, x.Timeout)
+	fmt.Println(reg.Flags(fs))
+	var y struct {
+		Listen AddrPort `opt:"name=listen"`
+		Admin  AddrPort `opt:"name=listen"`
+	}
+	fmt.Println(new(Registry).Bind(&y))
+	// Output:
+	//   -listen value
+	//     	listen address (default 0.0.0.0:80)
+	//   -timeout value
+	//     	idle timeout, 0 for none (default 30s)
+	//   -v	verbose
+	// 127.0.0.1:80 true 30s
+	// -listen duplicate
+	// admin: -listen duplicate
+}
+
+func ExampleFileSource() {
+	dir, err := os.MkdirTemp("", "opt")
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	defer os.RemoveAll(dir)
+	os.WriteFile(filepath.Join(dir, "DB_PASSWORD"), []byte("s3cr3t\n"), 0600)
+	os.WriteFile(filepath.Join(dir, "db.user"), []byte("admin\n"), 0600)
+	os.WriteFile(filepath.Join(dir, "token"), []byte("t0k3n\n"), 0600)
+	os.WriteFile(filepath.Join(dir, "other"), []byte("ignored\n"), 0600)
+
+	var reg Registry
+	password, user, token := NewSecret(""), NewString(""), NewSecret("")
+	reg.Register("db.password", password)
+	reg.Register("db.user", user)
+	reg.Register("api.token", token)
+	if err := NewFileSource(&reg, System, dir).Load(); err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Println(user, user.Source().Name[len(dir)+1:])
+	fmt.Println(password, string(password.Reveal()))
+
+	err = NewEnv("MYAPP", &reg).Set(
+		"MYAPP_API_TOKEN_FILE=" + filepath.Join(dir, "token"),
+	)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Println(token.Source().Name, string(token.Reveal()))
+	fmt.Println(NewEnv("MYAPP", &reg).Set(
+		"MYAPP_API_TOKEN=t0k3n",
+		"MYAPP_API_TOKEN_FILE="+filepath.Join(dir, "token"),
+	))
+	// Output:
+	// admin db.user
+	// [redacted] s3cr3t
+	// MYAPP_API_TOKEN t0k3n
+	// MYAPP_API_TOKEN and MYAPP_API_TOKEN_FILE duplicate
+}
+
+func ExampleRegistry_Load() {
+	dir, err := os.MkdirTemp("", "opt")
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	defer os.RemoveAll(dir)
+	system := filepath.Join(dir, "system.toml")
+	user := filepath.Join(dir, "user.yaml")
+	os.WriteFile(system, []byte(`
+[scalar]
+duration = "15s"
+int = 1
+`), 0644)
+	os.WriteFile(user, []byte(`
+scalar:
+  duration: 30s
+`), 0644)
+
+	var x StructExample
+	var reg Registry
+	reg.Bind(&x)
+	for _, load := range []struct {
+		l    Layer
+		path string
+	}{
+		{User, user},
+		{System, system},
+	} {
+		if err := reg.Load(load.l, load.path); err != nil {
+			fmt.Println(err)
+			return
+		}
+	}
+	fmt.Println(x.Scalar.Duration, x.Scalar.Int)
+	NewEnv("MYAPP", &reg).Set("MYAPP_SCALAR_DURATION=1m")
+	reg.Set("scalar.duration", "2m")
+	fmt.Println(x.Scalar.Duration)
+	reg.Unset("scalar.duration", Runtime)
+	fmt.Println(x.Scalar.Duration)
+	reg.Clear(Environment)
+	fmt.Println(x.Scalar.Duration)
+	reg.Clear(User)
+	fmt.Println(x.Scalar.Duration)
+	// Output:
+	// 30s 1
+	// 2m0s
+	// 1m0s
+	// 30s
+	// 15s
+}
+
+func ExampleLoader() {
+	dir, err := os.MkdirTemp("", "opt")
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "app.toml")
+	os.WriteFile(path, []byte("duration = \"15s\"\nint = 1\n"), 0644)
+
+	var x StructExample
+	var reg Registry
+	reg.Bind(&x.Scalar)
+	ld := NewLoader(&reg, System, path)
+	if err := ld.Load(); err != nil {
+		fmt.Println(err)
+		return
+	}
+	ch := make(chan Change)
+	Subscribe(ch)
+	defer Unsubscribe(ch)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	errs := ld.Watch(ctx, 0)
+	os.WriteFile(path, []byte("duration = \"15s\"\nint = 2\n"), 0644)
+	select {
+	case c := <-ch:
+		fmt.Println(c)
+	case err := <-errs:
+		fmt.Println(err)
+	case <-time.After(10 * time.Second):
+		fmt.Println("timeout")
+	}
+	// Output: int: 1 -> 2
+}
+
+func ExampleLoader_Load() {
+	dir, err := os.MkdirTemp("", "opt")
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "app.toml")
+
+	var reg Registry
+	password := NewSecret("")
+	reg.Register("password", password)
+	ld := NewLoader(&reg, System, path)
+	for _, s := range []string{"one", "two"} {
+		os.WriteFile(path, []byte("password = \""+s+"\"\n"), 0600)
+		if err := ld.Load(); err != nil {
+			fmt.Println(err)
+			return
+		}
+		fmt.Println(password, string(password.Reveal()))
+	}
+	// Output:
+	// [redacted] one
+	// [redacted] two
+}
+
+func ExampleRegistry_Dump() {
+	dir, err := os.MkdirTemp("", "opt")
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "app.toml")
+	os.WriteFile(path, []byte("int = 7\n"), 0644)
+
+	var x StructExample
+	var reg Registry
+	reg.Bind(&x.Scalar)
+	reg.Load(System, path)
+	NewEnv("MYAPP", &reg).Set("MYAPP_DURATION=1m")
+	fs, _ := reg.FlagSet("example", flag.ContinueOnError)
+	fs.Parse([]string{"-addr", "10.1.1.1"})
+	reg.SetSource("url", Source{Layer: Runtime, Name: "admin"},
+		"https://golang.org")
+	for _, setting := range reg.Dump() {
+		src := setting.Source
+		src.Name = strings.TrimPrefix(src.Name, dir)
+		src.Time = time.Time{}
+		fmt.Println(setting.Name, setting.Value, src)
+	}
+	// Output:
+	// addr 10.1.1.1 command-line -addr
+	// addr_port invalid AddrPort default
+	// bool false default
+	// duration 1m0s environment MYAPP_DURATION
+	// float 0 default
+	// int 7 system /app.toml:int
+	// prefix invalid Prefix default
+	// string  default
+	// url https://golang.org runtime admin
+}
+
+func ExampleRegistry_Reset() {
+	var reg Registry
+	timeout := NewDuration(time.Second)
+	retries := NewNumber(3)
+	reg.Register("timeout", timeout)
+	reg.Register("retries", retries)
+	reg.SetSource("timeout", Source{Layer: System}, "5s")
+	retries.Set("5")
+	fmt.Println(timeout.IsDefault(), retries.IsDefault())
+	retries.Reset()
+	fmt.Println(retries, retries.IsDefault())
+	reg.Reset()
+	fmt.Println(timeout, timeout.Default(), timeout.IsDefault())
+	// Output:
+	// false false
+	// 3 true
+	// 1s 1s true
+}
+
+func ExampleKeepHistory() {
+	retries := NewNumber(3)
+	KeepHistory(retries, 2)
+	for _, s := range []string{"4", "5", "6"} {
+		retries.Set(s)
+	}
+	for _, c := range History(retries) {
+		fmt.Println(c.OldText(), "->", c.NewText(), c.Source.Layer)
+	}
+	// Output:
+	// 4 -> 5 runtime
+	// 5 -> 6 runtime
+}
+
+func ExampleAuditLog() {
+	var reg Registry
+	timeout := NewDuration(time.Second)
+	reg.Register("timeout", timeout)
+	buf := new(strings.Builder)
+	log := NewAuditLog(buf)
+	SetAuditor(log)
+	reg.SetSource("timeout", Source{
+		Layer: Runtime,
+		Name:  "admin",
+		Actor: "alice",
+	}, "5s")
+	SetAuditor(nil)
+	if err := log.Close(); err != nil {
+		fmt.Println(err)
+		return
+	}
+	var r struct {
+		Name, Old, New string
+		Source         Source
+	}
+	json.Unmarshal([]byte(buf.String()), &r)
+	fmt.Println(r.Name, r.Old, r.New, r.Source.Name, r.Source.Actor)
+	// Output: timeout 1s 5s admin alice
+}
+
+func ExampleRegistry_ServeHTTP() {
+	var reg Registry
+	reg.Register("server.timeout", NewDuration(time.Second))
+	reg.Register("server.retries", LimitedNumber(3, 0, 200))
+	srv := httptest.NewServer(&reg)
+	defer srv.Close()
+	do := func(method, path, body string) {
+		req, _ := http.NewRequest(method, srv.URL+path,
+			strings.NewReader(body))
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+		defer resp.Body.Close()
+		b, _ := io.ReadAll(resp.Body)
+		fmt.Println(strings.TrimSpace(fmt.Sprint(resp.StatusCode, " ",
+			string(b))))
+	}
+	do("PUT", "/server/timeout", "5s")
+	do("PUT", "/server.retries", "201")
+	do("PATCH", "/server", `{"retries": 5, "timeout": "10s"}`)
+	do("GET", "/server/retries", "")
+	do("GET", "/", "")
+	do("GET", "/client", "")
+	// Output:
+	// 204
+	// 400 server.retries: 201 > max{200}
+	// 204
+	// 200 5
+	// 200 {
+	// 	"server": {
+	// 		"retries": 5,
+	// 		"timeout": "10s"
+	// 	}
+	// }
+	// 404 "client" not found
+}
+
+func ExampleWithActor() {
+	var reg Registry
+	timeout := NewDuration(time.Second)
+	reg.Register("timeout", timeout)
+	users := map[string]string{"alice": "s3cr3t"}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter,
+		r *http.Request) {
+		user, password, ok := r.BasicAuth()
+		if !ok || users[user] != password {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		reg.ServeHTTP(w, r.WithContext(WithActor(r.Context(), user)))
+	}))
+	defer srv.Close()
+	req, _ := http.NewRequest("PUT", srv.URL+"/timeout",
+		strings.NewReader("5s"))
+	req.SetBasicAuth("alice", "s3cr3t")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	resp.Body.Close()
+	fmt.Println(resp.StatusCode, timeout, timeout.Source().Actor)
+	// Output: 204 5s alice
+}
+
+func ExampleRegistry_Serve() {
+	dir, err := os.MkdirTemp("", "opt")
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	defer os.RemoveAll(dir)
+	socket := filepath.Join(dir, "opt.sock")
+	l, err := net.Listen("unix", socket)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	defer l.Close()
+	var reg Registry
+	reg.Register("server.timeout", NewDuration(time.Second))
+	reg.Register("server.retries", LimitedNumber(3, 0, 200))
+	go reg.Serve(l)
+
+	conn, err := net.Dial("unix", socket)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	defer conn.Close()
+	r := bufio.NewScanner(conn)
+	for _, req := range []string{
+		"set server.timeout 5s",
+		"set server.retries 201",
+		"get server",
+		"list",
+		"watch server.retries",
+	} {
+		fmt.Fprintln(conn, req)
+		for r.Scan() {
+			fmt.Println(r.Text())
+			if r.Text() == "ok" || strings.HasPrefix(r.Text(), "error") {
+				break
+			}
+		}
+	}
+	reg.Set("server.retries", "5")
+	r.Scan()
+	fmt.Println(r.Text())
+	// Output:
+	// ok
+	// error 201 > max{200}
+	// server.retries = 3
+	// server.timeout = 5s
+	// ok
+	// server.retries
+	// server.timeout
+	// ok
+	// ok
+	// server.retries = 5
+}
+
+func ExampleRegistry_Save() {
+	dir, err := os.MkdirTemp("", "opt")
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	defer os.RemoveAll(dir)
+	var x StructExample
+	x.Slice.Structs = make([]struct {
+		Number Number[int]
+		String String[string]
+	}, 2)
+	var reg Registry
+	reg.Bind(&x)
+	reg.Set("scalar.duration", "5s")
+	reg.Set("slice.prefixes", "[10.0.0.0/8, 192.168.0.0/16]")
+	reg.Set("slice.structs.1.string", "hello world")
+	path := filepath.Join(dir, "app.toml")
+	os.WriteFile(filepath.Join(dir, "real.toml"), nil, 0600)
+	os.Symlink("real.toml", path)
+	if err := reg.Save(path, "", true); err != nil {
+		fmt.Println(err)
+		return
+	}
+	b, _ := os.ReadFile(path)
+	fmt.Print(string(b))
+	if fi, err := os.Lstat(path); err == nil {
+		fmt.Println(fi.Mode().Type() == os.ModeSymlink)
+	}
+	if fi, err := os.Stat(path); err == nil {
+		fmt.Println(fi.Mode())
+	}
+	for _, format := range []string{"toml", "yaml", "json"} {
+		path := filepath.Join(dir, "app."+format)
+		if err := reg.Save(path, format, false); err != nil {
+			fmt.Println(err)
+			return
+		}
+		var y StructExample
+		y.Slice.Structs = make([]struct {
+			Number Number[int]
+			String String[string]
+		}, 2)
+		var loaded Registry
+		loaded.Bind(&y)
+		if err := loaded.Load(User, path); err != nil {
+			fmt.Println(err)
+			return
+		}
+		a, b := reg.Dump(), loaded.Dump()
+		for i := range a {
+			if a[i].Value != b[i].Value {
+				fmt.Println(format, a[i].Name, a[i].Value, b[i].Value)
+			}
+		}
+	}
+	// Output:
+	// [scalar]
+	//   duration = "5s"
+	//
+	// [slice]
+	//   prefixes = ["10.0.0.0/8", "192.168.0.0/16"]
+	//   [slice.structs]
+	//     [slice.structs.1]
+	//       string = "hello world"
+	// true
+	// -rw-------
+}
+
+func ExampleRegistry_Diff() {
+	dir, err := os.MkdirTemp("", "opt")
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "app.yaml")
+	os.WriteFile(path, []byte(`
+server:
+  timeout: 2m
+  retries: 5
+  listen: ":8080"
+`), 0644)
+
+	var reg Registry
+	reg.Register("server.timeout", NewDuration(time.Minute))
+	reg.Register("server.retries", NewNumber(3))
+	reg.Register("server.name", NewString("www"))
+	reg.SetSource("server.name", Source{Layer: System}, "web")
+	reg.Set("server.timeout", "30s")
+	texts, err := ReadFile(path)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	diff := reg.Diff(System, texts)
+	fmt.Print(diff)
+	fmt.Print(diff.Unified("running", path[len(dir)+1:]))
+	b, _ := json.Marshal(diff[0])
+	fmt.Println(string(b))
+	// Output:
+	// added server.listen = :8080
+	// changed server.name: web -> www
+	// changed server.retries: 3 -> 5
+	// --- running
+	// +++ app.yaml
+	// +server.listen = :8080
+	// -server.name = web
+	// +server.name = www
+	// -server.retries = 3
+	// +server.retries = 5
+	// {"Edit":"added","Name":"server.listen","Old":"","New":":8080"}
+}
+
+func ExampleRegistry_Snapshot() {
+	var reg Registry
+	timeout := NewDuration(time.Second)
+	retries := LimitedNumber(3, 0, 200)
+	reg.Register("link.timeout", timeout)
+	reg.Register("link.retries", retries)
+	reg.SetSource("link.timeout", Source{Layer: System}, "5s")
+	b, err := json.Marshal(reg.Snapshot())
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	var tx Tx
+	tx.Set(timeout, "1m")
+	tx.Set(retries, "100")
+	tx.Commit()
+	fmt.Println(timeout, retries)
+
+	ch := make(chan Change)
+	Subscribe(ch)
+	defer Unsubscribe(ch)
+	s := new(Snapshot)
+	if err := json.Unmarshal(b, s); err != nil {
+		fmt.Println(err)
+		return
+	}
+	if err := reg.Restore(s); err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Println(timeout, timeout.Source().Layer, retries)
+	fmt.Println(<-ch)
+	// Output:
+	// 1m0s 100
+	// 5s system 3
+	// link.retries: 100 -> 3
+	// link.timeout: 1m0s -> 5s
+}
+
+func ExampleRegistry_Restore() {
+	var x StructExample
+	var reg Registry
+	reg.Bind(&x)
+	s := reg.Snapshot()
+	reg.Set("scalar.addr", "10.1.1.1")
+	fmt.Println(x.Scalar.Addr)
+	fmt.Println(reg.Restore(s), x.Scalar.Addr)
+	// Output:
+	// 10.1.1.1
+	// <nil> invalid IP
+}
+
+func ExampleSchema() {
+	type Route struct {
+		Prefix  Prefix
+		Via     Addr
+		Metric  *Number[uint8]
+		Hold    *Duration `opt:"usage=hold down time"`
+		Protect *String[string]
+	}
+	var config struct {
+		Routes []Route
+	}
+	config.Routes = []Route{{
+		Metric:  LimitedNumber[uint8](1, 1, 16),
+		Hold:    NewDuration(time.Second),
+		Protect: Alias("none", "link", "node"),
+	}}
+	b, err := Schema(&config)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Println(string(b))
+	// Output:
+	// {
+	// 	"$schema": "https://json-schema.org/draft/2020-12/schema",
+	// 	"additionalProperties": false,
+	// 	"properties": {
+	// 		"routes": {
+	// 			"items": {
+	// 				"additionalProperties": false,
+	// 				"properties": {
+	// 					"hold": {
+	// 						"description": "hold down time",
+	// 						"pattern": "^[-+]?(0|(([0-9]+(\\.[0-9]*)?|\\.[0-9]+)(ns|us|µs|μs|ms|s|m|h))+)$",
+	// 						"type": "string"
+	// 					},
+	// 					"metric": {
+	// 						"maximum": 16,
+	// 						"minimum": 1,
+	// 						"type": "integer"
+	// 					},
+	// 					"prefix": {
+	// 						"pattern": "^[0-9A-Fa-f:.]+/[0-9]{1,3}$",
+	// 						"type": "string"
+	// 					},
+	// 					"protect": {
+	// 						"enum": [
+	// 							"link",
+	// 							"node",
+	// 							"none"
+	// 						],
+	// 						"type": "string"
+	// 					},
+	// 					"via": {
+	// 						"anyOf": [
+	// 							{
+	// 								"format": "ipv4"
+	// 							},
+	// 							{
+	// 								"format": "ipv6"
+	// 							}
+	// 						],
+	// 						"type": "string"
+	// 					}
+	// 				},
+	// 				"type": "object"
+	// 			},
+	// 			"type": "array"
+	// 		}
+	// 	},
+	// 	"type": "object"
+	// }
+}
+
+func ExampleSecret() {
+	var reg Registry
+	token := NewSecret("")
+	reg.Register("api.token", token)
+	KeepHistory(token, 1)
+	reg.Set("api.token", "s3cr3t")
+	old := token.Reveal()
+	reg.SetSource("api.token", Source{Layer: Runtime}, "t0ps3cr3t")
+	fmt.Println(token, fmt.Sprintf("%q %#v", token, token))
+	b, _ := json.Marshal(token)
+	fmt.Println(string(b), History(token)[0])
+	fmt.Println(string(token.Reveal()), old)
+	s := reg.Snapshot()
+	fmt.Println(s.Settings()[1].Value)
+	reg.Set("api.token", "n3wt0k3n")
+	reg.Restore(s)
+	fmt.Println(string(token.Reveal()))
+	// Output:
+	// [redacted] [redacted] [redacted]
+	// "[redacted]" api.token: [redacted] -> [redacted]
+	// t0ps3cr3t [0 0 0 0 0 0]
+	// [redacted]
+	// t0ps3cr3t
+}
+
+func ExampleRegistry_Expand() {
+	dir, err := os.MkdirTemp("", "opt")
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "app.toml")
+	os.WriteFile(path, []byte(`
+host = "db.example.com"
+[api]
+url = "https://${host}:${MYAPP_PORT:-8443}/v1"
+[metrics]
+url = "https://${host}:${MYAPP_PORT:-8443}/metrics"
+`), 0644)
+
+	var config struct {
+		Host     String[string]
+		Password Secret
+		API      struct {
+			URL URL `opt:"expand=true"`
+		} `toml:"api"`
+		Metrics struct {
+			URL URL
+		}
+	}
+	var reg Registry
+	reg.Bind(&config)
+	reg.Expand("metrics")
+	if err := reg.Load(System, path); err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Println(config.API.URL)
+	os.Setenv("MYAPP_PORT", "443")
+	defer os.Unsetenv("MYAPP_PORT")
+	reg.Set("metrics.url", "https://${host}:${MYAPP_PORT}/metrics")
+	fmt.Println(config.Metrics.URL)
+	fmt.Println(reg.Set("metrics.url", "https://${hostname}/metrics"))
+	fmt.Println(reg.Set("api.url", "https://${api.url}"))
+	fmt.Println(reg.Set("api.url", "https://admin:${password}@${host}/v1"))
+	// Output:
+	// https://db.example.com:8443/v1
+	// https://db.example.com:443/metrics
+	// "${hostname}" undefined
+	// "api.url -> api.url" cycle
+	// "${password}" secret
+}
+
+func ExampleStrings() {
+	var x, y Strings[string]
+	x.Store([]string{"a\nb", "c d", "e"})
+	fmt.Println(x)
+	y.Set(x.String())
+	fmt.Printf("%q\n", y.Value())
+	// Output:
+	// ["a\nb" "c d" e]
+	// ["a\nb" "c d" "e"]
+}
+
 func ExampleSubscribe() {
-	var updates int
+	var reg Registry
 	var n Number[int]
-	pn := uintptr(unsafe.Pointer(&n))
-	ch := make(chan uintptr, 4)
+	reg.Register("n", &n)
+	ch := make(chan Change)
 	Subscribe(ch)
 	defer Unsubscribe(ch)
-	go func() {
-		defer close(ch)
-		for _, mod := range []int{3, 2, 1} {
-			n.Store(mod)
-		}
-	}()
-	for p := range ch {
-		if p != pn {
+	for _, mod := range []int{3, 2, 1} {
+		n.Store(mod)
+	}
+	for i := 0; i < 3; i++ {
+		c := <-ch
+		if c.Opt != &n {
 			fmt.Println("mismatch")
 		} else {
-			updates += 1
+			fmt.Println(c.Name, c.Old.(int), c.New.(int))
+		}
+	}
+	// Output:
+	// n 0 3
+	// n 3 2
+	// n 2 1
+}
+
+func ExampleSubscribePolicy() {
+	var n Number[int]
+	ch := make(chan Change)
+	SubscribePolicy(ch, Coalesce)
+	defer Unsubscribe(ch)
+	// the receiver stalls until the last store, so all but the change
+	// that's being sent are merged into one
+	for i := 1; i <= 100; i++ {
+		n.Store(i)
+	}
+	var received, old int
+	for c := range ch {
+		received++
+		if c.Old.(int) != old {
+			fmt.Println("missing", old, "->", c.Old)
+		}
+		if old = c.New.(int); old == 100 {
+			break
+		}
+	}
+	fmt.Println(received <= 2, old)
+	// Output: true 100
+}
+
+func ExampleSubscribePolicy_dropOldest() {
+	var n Number[int]
+	ch := make(chan Change)
+	SubscribePolicy(ch, DropOldest)
+	defer Unsubscribe(ch)
+	// with an unbuffered channel, only the change that's being sent and
+	// the latest are kept
+	for i := 1; i <= 100; i++ {
+		n.Store(i)
+	}
+	var received int
+	for c := range ch {
+		received++
+		if c.New.(int) == 100 {
+			fmt.Println(received <= 2, c.Old, c.New)
+			break
 		}
 	}
-	fmt.Print(updates)
-	// Output: 3
+	// Output: true 99 100
+}
+
+func ExampleNumber_Watch() {
+	var n, other Number[int]
+	ctx, cancel := context.WithCancel(context.Background())
+	w := n.Watch(ctx)
+	fmt.Println(<-w)
+	other.Store(10)
+	n.Store(1)
+	fmt.Println(<-w)
+	cancel()
+	for range w {
+	}
+	fmt.Println("closed")
+	// Output:
+	// 0
+	// 1
+	// closed
+}
+
+func ExampleTx() {
+	var reg Registry
+	addr := MustParseAddr("10.0.0.1")
+	prefix := MustParsePrefix("10.0.0.0/24")
+	hold := LimitedDuration(3*time.Second, time.Second, 5*time.Second)
+	reg.Register("route.addr", addr)
+	reg.Register("route.prefix", prefix)
+	reg.Register("route.hold", hold)
+	ch := make(chan Change, 1)
+	Subscribe(ch)
+	defer Unsubscribe(ch)
+
+	var tx Tx
+	tx.Set(addr, "10.1.0.1")
+	tx.Store(prefix, netip.MustParsePrefix("10.1.0.0/24"))
+	tx.Set(hold, "10s")
+	fmt.Println(tx.Commit())
+	fmt.Println(addr, prefix, hold)
+
+	tx.Set(addr, "10.1.0.1")
+	tx.Store(prefix, netip.MustParsePrefix("10.1.0.0/24"))
+	tx.Set(hold, "4s")
+	fmt.Println(tx.Commit())
+	c := <-ch
+	fmt.Println(len(c.Group))
+	fmt.Println(c)
+	// Output:
+	// route.hold: 10s > max{5s}
+	// 10.0.0.1 10.0.0.0/24 3s
+	// <nil>
+	// 3
+	// route.addr: 10.0.0.1 -> 10.1.0.1
+	// route.prefix: 10.0.0.0/24 -> 10.1.0.0/24
+	// route.hold: 3s -> 4s
+}
+
+func ExampleView() {
+	var x StructExample
+	x.Scalar.Addr.Set("10.1.1.1")
+	x.Scalar.AddrPort.Set("10.1.1.1:80")
+	go func() {
+		var tx Tx
+		tx.Set(&x.Scalar.Addr, "10.2.2.2")
+		tx.Set(&x.Scalar.AddrPort, "10.2.2.2:80")
+		tx.Commit()
+	}()
+	var addr netip.Addr
+	var ap netip.AddrPort
+	View(func() {
+		addr = x.Scalar.Addr.Value()
+		ap = x.Scalar.AddrPort.Value()
+	})
+	fmt.Println(addr == ap.Addr())
+	// Output: true
 }
 
 func Example_struct_init() {
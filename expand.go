@@ -0,0 +1,153 @@
+// Copyright © 2021-2022 Platina Systems, Inc. All rights reserved.
+// Use of this source code is governed by the GPL-2 license described in the
+// LICENSE file.
+
+package opt
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Expand references in the text values set through the registry for the
+// options equal to or within the given paths, e.g. by Set, Load, NewEnv,
+// Flags and ServeHTTP; a field tagged `opt:"expand=true"` is bound with
+// them expanded. The references are
+//
+//	${name}             the text of the option or environment variable
+//	${name:-default}    or default if that's unset or empty
+//	$$                  a literal $
+//
+// where a registered name is the value being set with it, e.g. by the same
+// file, or else the option's current value. A reference that's undefined,
+// refers back to itself or to a Secret is an error.
+//
+// Only the registry expands references: an option's own Set, e.g. that of a
+// String or URL, and decoding a file directly into a bound struct store the
+// text as is.
+func (reg *Registry) Expand(paths ...string) error {
+	reg.mutex.Lock()
+	defer reg.mutex.Unlock()
+	for _, path := range paths {
+		found := false
+		for name, e := range reg.opts {
+			if within(name, path) {
+				e.expand = true
+				found = true
+			}
+		}
+		if !found {
+			return fmt.Errorf("%q %w", path, ErrNotFound)
+		}
+	}
+	return nil
+}
+
+// expander resolves the references of text values set together.
+type expander struct {
+	reg   *Registry
+	texts map[string]string
+	stack []string
+}
+
+// text returns s with its references expanded if the named option expands
+// them; texts has the values being set with it by name.
+func (reg *Registry) text(name, s string, texts map[string]string) (string, error) {
+	if !reg.expands(name) {
+		return s, nil
+	}
+	x := &expander{reg: reg, texts: texts, stack: []string{name}}
+	return x.expand(s)
+}
+
+func (reg *Registry) expands(name string) bool {
+	reg.mutex.RLock()
+	defer reg.mutex.RUnlock()
+	e := reg.opts[name]
+	return e != nil && e.expand
+}
+
+func (x *expander) expand(s string) (string, error) {
+	sb := new(strings.Builder)
+	for {
+		i := strings.IndexByte(s, '$')
+		if i < 0 || i+1 == len(s) {
+			sb.WriteString(s)
+			return sb.String(), nil
+		}
+		sb.WriteString(s[:i])
+		s = s[i+1:]
+		switch s[0] {
+		case '$':
+			sb.WriteByte('$')
+			s = s[1:]
+			continue
+		case '{':
+		default:
+			sb.WriteByte('$')
+			continue
+		}
+		end, depth := 1, 1
+		for ; end < len(s) && depth > 0; end++ {
+			switch s[end] {
+			case '{':
+				depth++
+			case '}':
+				depth--
+			}
+		}
+		if depth > 0 {
+			return "", fmt.Errorf("%q unterminated", "$"+s)
+		}
+		ref := s[1 : end-1]
+		s = s[end:]
+		name, def, hasDefault := strings.Cut(ref, ":-")
+		v, err := x.resolve(name)
+		if err != nil {
+			return "", err
+		}
+		if v == nil || len(*v) == 0 && hasDefault {
+			if !hasDefault {
+				return "", fmt.Errorf("%q undefined", "${"+name+"}")
+			}
+			if def, err = x.expand(def); err != nil {
+				return "", err
+			}
+			v = &def
+		}
+		sb.WriteString(*v)
+	}
+}
+
+// resolve the named option or environment variable, or return nil if
+// neither is set.
+func (x *expander) resolve(name string) (*string, error) {
+	for i, s := range x.stack {
+		if s == name {
+			cycle := append(x.stack[i:len(x.stack):len(x.stack)], name)
+			return nil, fmt.Errorf("%q cycle", strings.Join(cycle, " -> "))
+		}
+	}
+	opt := x.reg.Lookup(name)
+	if _, ok := opt.(*Secret); ok {
+		return nil, fmt.Errorf("%q secret", "${"+name+"}")
+	}
+	if s, found := x.texts[name]; found {
+		if !x.reg.expands(name) {
+			return &s, nil
+		}
+		x.stack = append(x.stack, name)
+		defer func() { x.stack = x.stack[:len(x.stack)-1] }()
+		s, err := x.expand(s)
+		return &s, err
+	}
+	if opt != nil {
+		s := textof(opt)
+		return &s, nil
+	}
+	if s, found := os.LookupEnv(name); found {
+		return &s, nil
+	}
+	return nil, nil
+}
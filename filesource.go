@@ -0,0 +1,94 @@
+// Copyright © 2021-2022 Platina Systems, Inc. All rights reserved.
+// Use of this source code is governed by the GPL-2 license described in the
+// LICENSE file.
+
+package opt
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// FileSource loads a directory with a file per option, like
+// /run/secrets or $CREDENTIALS_DIRECTORY, into a layer of the registered
+// options. Each file is named by the option's path, e.g. "db.password", or
+// by its environment variable without prefix, "DB_PASSWORD", in any case;
+// other files are ignored.
+type FileSource struct {
+	mutex  sync.Mutex
+	reg    *Registry
+	src    Source
+	loaded map[string]bool
+}
+
+func NewFileSource(reg *Registry, l Layer, dir string) *FileSource {
+	return &FileSource{reg: reg, src: Source{Layer: l, Name: dir}}
+}
+
+// Load the trimmed contents of each file into its option in one transaction
+// that changes nothing if any is invalid. Options loaded before whose file
+// has since been removed have the layer removed.
+func (fs *FileSource) Load() error {
+	fs.mutex.Lock()
+	defer fs.mutex.Unlock()
+	dir := fs.src.Name
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	byFile := make(map[string]string)
+	for _, name := range fs.reg.List() {
+		byFile[strings.ToLower(name)] = name
+		byFile[strings.ToLower(envname("", name))] = name
+		if e := fs.reg.entry(name); e != nil && len(e.env) > 0 && e.env != "-" {
+			byFile[strings.ToLower(e.env)] = name
+		}
+	}
+	var tx Tx
+	loaded := make(map[string]bool)
+	for _, de := range entries {
+		name, found := byFile[strings.ToLower(de.Name())]
+		if !found {
+			continue
+		}
+		path := filepath.Join(dir, de.Name())
+		if fi, err := os.Stat(path); err != nil || !fi.Mode().IsRegular() {
+			continue
+		}
+		b, err := os.ReadFile(path)
+		if err != nil {
+			tx.fail(err)
+			continue
+		}
+		s, err := fs.reg.text(name, strings.TrimSpace(string(b)), nil)
+		if err != nil {
+			tx.fail(fmt.Errorf("%s: %w", name, err))
+			continue
+		}
+		src := fs.src
+		src.Name = path
+		tx.stage(fs.reg.Lookup(name), src, s)
+		loaded[name] = true
+	}
+	var gone []string
+	for name := range fs.loaded {
+		if !loaded[name] {
+			gone = append(gone, name)
+		}
+	}
+	sort.Strings(gone)
+	for _, name := range gone {
+		if opt := fs.reg.Lookup(name); opt != nil {
+			tx.stage(opt, Source{Layer: fs.src.Layer}, nil)
+		}
+	}
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("%s: %w", dir, err)
+	}
+	fs.loaded = loaded
+	return nil
+}
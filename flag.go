@@ -0,0 +1,93 @@
+// Copyright © 2021-2022 Platina Systems, Inc. All rights reserved.
+// Use of this source code is governed by the GPL-2 license described in the
+// LICENSE file.
+
+package opt
+
+import (
+	"flag"
+	"fmt"
+	"reflect"
+)
+
+// FlagSet returns a new flag set of the registered options.
+func (reg *Registry) FlagSet(name string, handling flag.ErrorHandling) (*flag.FlagSet, error) {
+	fs := flag.NewFlagSet(name, handling)
+	if err := reg.Flags(fs); err != nil {
+		return nil, err
+	}
+	return fs, nil
+}
+
+// Flags defines a flag for each registered option in the given set, e.g.
+// flag.CommandLine. Each flag is named by its `opt:"name=..."` tag, or
+// else the option path, has the tagged usage and the current value as its
+// default, and sets the option's CommandLine layer. If any flag is already
+// defined in the set, Flags defines none of them.
+func (reg *Registry) Flags(fs *flag.FlagSet) error {
+	paths := reg.List()
+	for _, path := range paths {
+		if e := reg.entry(path); e != nil {
+			if name := e.flagname(path); len(name) > 0 &&
+				fs.Lookup(name) != nil {
+				return fmt.Errorf("-%s duplicate", name)
+			}
+		}
+	}
+	for _, path := range paths {
+		e := reg.entry(path)
+		if e == nil {
+			continue
+		}
+		name := e.flagname(path)
+		if len(name) == 0 {
+			continue
+		}
+		fs.Var(flagValue{reg, e.opt, path, "-" + name}, name, e.usage)
+		// flag.PrintDefaults can't tell the zero of the wrapped option
+		if f := fs.Lookup(name); f.DefValue == zero(e.opt) {
+			f.DefValue = ""
+		}
+	}
+	return nil
+}
+
+// flagValue sets the CommandLine layer of its option registered by path.
+type flagValue struct {
+	reg  *Registry
+	opt  Option
+	path string
+	name string
+}
+
+func (f flagValue) IsBoolFlag() bool {
+	b, ok := f.opt.(interface{ IsBoolFlag() bool })
+	return ok && b.IsBoolFlag()
+}
+
+func (f flagValue) Set(s string) error {
+	s, err := f.reg.text(f.path, s, nil)
+	if err != nil {
+		return err
+	}
+	return apply(f.opt, Source{Layer: CommandLine, Name: f.name}, s)
+}
+
+func (f flagValue) String() string {
+	if f.opt == nil {
+		return ""
+	}
+	return f.opt.String()
+}
+
+// zero returns the text form of a new option of the same type.
+func zero(opt Option) string {
+	t := reflect.TypeOf(opt)
+	if t.Kind() != reflect.Pointer {
+		return ""
+	}
+	if z, ok := reflect.New(t.Elem()).Interface().(Option); ok {
+		return z.String()
+	}
+	return ""
+}
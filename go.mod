@@ -1,3 +1,8 @@
 module github.com/platinasystems/opt
 
 go 1.18
+
+require (
+	github.com/BurntSushi/toml v1.6.0
+	gopkg.in/yaml.v2 v2.4.0
+)
@@ -0,0 +1,171 @@
+// Copyright © 2021-2022 Platina Systems, Inc. All rights reserved.
+// Use of this source code is governed by the GPL-2 license described in the
+// LICENSE file.
+
+package opt
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// MaxBody is the size limit of a request body that ServeHTTP reads.
+const MaxBody = 1 << 20
+
+// ServeHTTP administers the registered options by URL path, either dotted
+// or slashed, e.g. "/scalar.duration" or "/scalar/duration".
+//
+//	GET     JSON of the named option, or tree of options within the path
+//	PUT     set the Runtime layer of the named option from JSON or text,
+//	PATCH   or of each option within the path from a JSON object
+//	DELETE  unset the Runtime layer of the options within the path
+//
+// Stores are sourced by "http" and the actor of the request's context, if
+// authenticating middleware set one with WithActor. Invalid values and
+// bodies over MaxBody get 400 and unregistered paths 404.
+func (reg *Registry) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	path := strings.ReplaceAll(strings.Trim(r.URL.Path, "/"), "/", ".")
+	var err error
+	switch r.Method {
+	case http.MethodGet, http.MethodHead:
+		var b []byte
+		if b, err = reg.marshal(path); err == nil {
+			w.Header().Set("Content-Type", "application/json")
+			w.Write(append(b, '\n'))
+			return
+		}
+	case http.MethodPut, http.MethodPatch:
+		var b []byte
+		body := http.MaxBytesReader(w, r.Body, MaxBody)
+		if b, err = io.ReadAll(body); err == nil {
+			src := Source{Layer: Runtime, Name: "http"}
+			src.Actor, _ = r.Context().Value(actorKey{}).(string)
+			err = reg.put(path, src, b)
+		}
+	case http.MethodDelete:
+		err = reg.unset(path)
+	default:
+		w.Header().Set("Allow", "GET, HEAD, PUT, PATCH, DELETE")
+		http.Error(w, r.Method+" not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	switch {
+	case err == nil:
+		w.WriteHeader(http.StatusNoContent)
+	case errors.Is(err, ErrNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	default:
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	}
+}
+
+// actorKey is the context key of WithActor.
+type actorKey struct{}
+
+// WithActor returns a copy of ctx with the actor, e.g. the user name that
+// middleware authenticated, that ServeHTTP sources stores by.
+func WithActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+// marshal the named option, or the tree of those within the path, to JSON.
+func (reg *Registry) marshal(path string) ([]byte, error) {
+	if opt := reg.Lookup(path); opt != nil {
+		return marshal(opt)
+	}
+	var names []string
+	if len(path) == 0 {
+		names = reg.List()
+	} else {
+		names = reg.List(path)
+	}
+	if len(names) == 0 {
+		return nil, fmt.Errorf("%q %w", path, ErrNotFound)
+	}
+	tree, err := reg.tree(path, names, rawJSON)
+	if err != nil {
+		return nil, err
+	}
+	return json.MarshalIndent(tree, "", "\t")
+}
+
+// put the JSON or text form of the named option's value, or a JSON object of
+// those within the path, in one transaction.
+func (reg *Registry) put(path string, src Source, b []byte) error {
+	var texts map[string]string
+	b = bytes.TrimSpace(b)
+	if bytes.HasPrefix(b, []byte("{")) {
+		var err error
+		if texts, err = decode("json", b); err != nil {
+			return err
+		}
+	} else {
+		s := string(b)
+		if err := json.Unmarshal(b, &s); err != nil {
+			s = string(b)
+		}
+		texts = map[string]string{"": s}
+	}
+	byName := make(map[string]string, len(texts))
+	names := make([]string, 0, len(texts))
+	for k, s := range texts {
+		name := join(path, k)
+		if len(k) == 0 {
+			name = path
+		}
+		byName[name] = s
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var tx Tx
+	for _, name := range names {
+		opt, err := reg.lookup(name)
+		if err != nil {
+			tx.fail(err)
+			break
+		}
+		s, err := reg.text(name, byName[name], byName)
+		if err != nil {
+			tx.fail(fmt.Errorf("%s: %w", name, err))
+			break
+		}
+		tx.stage(opt, src, s)
+	}
+	return tx.Commit()
+}
+
+// unset the Runtime layer of the options within the path.
+func (reg *Registry) unset(path string) error {
+	var names []string
+	if len(path) == 0 {
+		names = reg.List()
+	} else if names = reg.List(path); len(names) == 0 {
+		return fmt.Errorf("%q %w", path, ErrNotFound)
+	}
+	var tx Tx
+	for _, name := range names {
+		if opt, ok := reg.Lookup(name).(stager); ok {
+			tx.stage(opt, Source{Layer: Runtime}, nil)
+		}
+	}
+	return tx.Commit()
+}
+
+func marshal(opt Option) ([]byte, error) {
+	if m, ok := opt.(json.Marshaler); ok && !isZero(opt) {
+		return m.MarshalJSON()
+	}
+	return json.Marshal(textof(opt))
+}
+
+func rawJSON(opt Option) (any, error) {
+	b, err := marshal(opt)
+	return json.RawMessage(b), err
+}
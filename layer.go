@@ -0,0 +1,115 @@
+// Copyright © 2021-2022 Platina Systems, Inc. All rights reserved.
+// Use of this source code is governed by the GPL-2 license described in the
+// LICENSE file.
+
+package opt
+
+import "fmt"
+
+// Layer of an option's values; the value of an option is that of its
+// highest layer set. Plain Set, Store and Unmarshal* methods write the
+// Runtime layer and constructors, or `opt:"default=..."` tags, the Default.
+type Layer uint8
+
+const (
+	Default Layer = iota
+	System
+	User
+	Environment
+	CommandLine
+	Runtime
+	nLayers
+)
+
+var layers = [nLayers]string{
+	Default:     "default",
+	System:      "system",
+	User:        "user",
+	Environment: "environment",
+	CommandLine: "command-line",
+	Runtime:     "runtime",
+}
+
+func (l Layer) MarshalText() ([]byte, error) {
+	return []byte(l.String()), nil
+}
+
+func (l Layer) String() string {
+	if l < nLayers {
+		return layers[l]
+	}
+	return fmt.Sprintf("layer(%d)", l)
+}
+
+func (l *Layer) UnmarshalText(text []byte) error {
+	for i, s := range layers {
+		if s == string(text) {
+			*l = Layer(i)
+			return nil
+		}
+	}
+	return fmt.Errorf("%q invalid", text)
+}
+
+// Clear the given layer of every registered option so that each falls back
+// to the value of its next lower layer. Options without layers, i.e. those
+// of other flag.Value types, are skipped.
+func (reg *Registry) Clear(l Layer) error {
+	var tx Tx
+	for _, name := range reg.List() {
+		if opt, ok := reg.Lookup(name).(stager); ok {
+			tx.stage(opt, Source{Layer: l}, nil)
+		}
+	}
+	return tx.Commit()
+}
+
+// Reset every registered option, or with prefixes, those within one of the
+// given paths, to its Default layer. Options without layers are skipped.
+func (reg *Registry) Reset(prefixes ...string) error {
+	var tx Tx
+	for _, name := range reg.List(prefixes...) {
+		if opt, ok := reg.Lookup(name).(stager); ok {
+			tx.Reset(opt)
+		}
+	}
+	return tx.Commit()
+}
+
+// SetSource sets the named option's layer of the given source from its
+// text form, e.g. Source{Layer: Runtime, Name: "admin"}.
+func (reg *Registry) SetSource(name string, src Source, s string) error {
+	opt, err := reg.lookup(name)
+	if err != nil {
+		return err
+	}
+	if s, err = reg.text(name, s, nil); err != nil {
+		return err
+	}
+	return apply(opt, src, s)
+}
+
+// Unset removes the given layer of the named option so that it falls back
+// to the value of its next lower layer.
+func (reg *Registry) Unset(name string, l Layer) error {
+	opt, err := reg.lookup(name)
+	if err != nil {
+		return err
+	}
+	return apply(opt, Source{Layer: l}, nil)
+}
+
+// apply a store of v, either typed or its text form, to the source's layer
+// of opt, or if v is nil, remove that layer.
+func apply(opt Option, src Source, v any) error {
+	st, ok := opt.(stager)
+	if !ok {
+		return fmt.Errorf("%T invalid", opt)
+	}
+	w, err := st.stage(src, v)
+	if err != nil {
+		return err
+	}
+	commit(w)
+	return nil
+}
@@ -0,0 +1,185 @@
+// Copyright © 2021-2022 Platina Systems, Inc. All rights reserved.
+// Use of this source code is governed by the GPL-2 license described in the
+// LICENSE file.
+
+package opt
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v2"
+)
+
+// Load a TOML, YAML or JSON file, by its extension, into the given layer of
+// the registered options, e.g. System for /etc and User for $HOME. The file
+// replaces the layer: options missing from it have that layer removed. If
+// the file has unregistered or invalid values, nothing is changed. Since
+// Bind registers slice elements by index, a list of tables, e.g.
+// [[slice.structs]], can't have more entries than the bound slice.
+func (reg *Registry) Load(l Layer, path string) error {
+	texts, err := readFile(path)
+	if err == nil {
+		err = reg.load(Source{Layer: l, Name: path}, texts, false)
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	return nil
+}
+
+// load the text form of values by path name into the source's layer; each
+// is sourced by its file and key, e.g. "/etc/app.toml:scalar.int". With
+// reload, values that the layer still has from the same source are skipped.
+func (reg *Registry) load(src Source, texts map[string]string, reload bool) error {
+	var tx Tx
+	names := make([]string, 0, len(texts))
+	for name := range texts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if opt := reg.Lookup(name); opt != nil {
+			src := src
+			if len(src.Name) > 0 {
+				src.Name += ":" + name
+			}
+			s, err := reg.text(name, texts[name], texts)
+			if err != nil {
+				tx.fail(fmt.Errorf("%s: %w", name, err))
+				continue
+			}
+			if reload && loaded(opt, src, s) {
+				continue
+			}
+			tx.stage(opt, src, s)
+		} else {
+			tx.fail(fmt.Errorf("%q %w", name, ErrNotFound))
+		}
+	}
+	for _, name := range reg.List() {
+		if _, found := texts[name]; found {
+			continue
+		}
+		if opt, ok := reg.Lookup(name).(stager); ok {
+			tx.stage(opt, Source{Layer: src.Layer}, nil)
+		}
+	}
+	return tx.Commit()
+}
+
+// loaded reports whether the source's layer of opt has the text form of a
+// value from the same source. Secrets are compared by their revealed text
+// since their settings are all Redacted.
+func loaded(opt Option, src Source, s string) bool {
+	var settings []Setting
+	switch opt := opt.(type) {
+	case *Secret:
+		settings = opt.reveal()
+	case stager:
+		settings, s = opt.settings(), format(opt, s)
+	default:
+		return false
+	}
+	for _, setting := range settings {
+		if setting.Source.Layer == src.Layer {
+			return setting.Source.Name == src.Name && setting.Value == s
+		}
+	}
+	return false
+}
+
+// formatof returns "toml", "yaml" or "json" by the file extension.
+func formatof(path string) string {
+	ext := strings.ToLower(strings.TrimPrefix(filepath.Ext(path), "."))
+	if ext == "yml" {
+		return "yaml"
+	}
+	return ext
+}
+
+// readFile returns the text form of each value in a TOML, YAML or JSON file
+// by its dotted path name.
+func readFile(path string) (map[string]string, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return decode(formatof(path), b)
+}
+
+func decode(format string, b []byte) (map[string]string, error) {
+	var tree any
+	var err error
+	switch format {
+	case "toml":
+		m := make(map[string]any)
+		_, err = toml.Decode(string(b), &m)
+		tree = m
+	case "yaml":
+		err = yaml.Unmarshal(b, &tree)
+	case "json":
+		dec := json.NewDecoder(bytes.NewReader(b))
+		dec.UseNumber()
+		err = dec.Decode(&tree)
+	default:
+		return nil, fmt.Errorf("%q format unknown", format)
+	}
+	if err != nil {
+		return nil, err
+	}
+	texts := make(map[string]string)
+	flatten(texts, "", tree)
+	return texts, nil
+}
+
+// flatten a decoded tree into the text form of its leaves by dotted path;
+// lists of tables are indexed like "slice.structs.0.number".
+func flatten(texts map[string]string, path string, v any) {
+	switch t := v.(type) {
+	case nil:
+	case map[string]any:
+		for k, sub := range t {
+			flatten(texts, join(path, k), sub)
+		}
+	case map[any]any:
+		for k, sub := range t {
+			flatten(texts, join(path, fmt.Sprint(k)), sub)
+		}
+	case []map[string]any:
+		for i, sub := range t {
+			flatten(texts, join(path, fmt.Sprint(i)), sub)
+		}
+	case []any:
+		for _, sub := range t {
+			switch sub.(type) {
+			case map[string]any, map[any]any:
+				for i, sub := range t {
+					flatten(texts, join(path, fmt.Sprint(i)), sub)
+				}
+				return
+			}
+		}
+		ses := make([]string, len(t))
+		for i, sub := range t {
+			ses[i] = quote(leaf(sub))
+		}
+		texts[path] = "[" + strings.Join(ses, ", ") + "]"
+	default:
+		texts[path] = leaf(t)
+	}
+}
+
+func leaf(v any) string {
+	if t, ok := v.(time.Time); ok {
+		return t.Format(time.RFC3339Nano)
+	}
+	return fmt.Sprint(v)
+}
@@ -0,0 +1,139 @@
+// Copyright © 2021-2022 Platina Systems, Inc. All rights reserved.
+// Use of this source code is governed by the GPL-2 license described in the
+// LICENSE file.
+
+package opt
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// Loader reloads a TOML, YAML or JSON file into a layer of the registered
+// options, staging only the values that the layer doesn't already have from
+// the file so that subscribers are notified once of just those.
+type Loader struct {
+	mutex sync.Mutex
+	reg   *Registry
+	src   Source
+}
+
+// stamp identifies a version of a polled file.
+type stamp struct {
+	mtime time.Time
+	size  int64
+}
+
+func NewLoader(reg *Registry, l Layer, path string) *Loader {
+	return &Loader{reg: reg, src: Source{Layer: l, Name: path}}
+}
+
+// Load the file like Registry.Load, except that values the layer already has
+// from the file aren't stored again.
+func (ld *Loader) Load() error {
+	ld.mutex.Lock()
+	defer ld.mutex.Unlock()
+	path := ld.src.Name
+	texts, err := readFile(path)
+	if err == nil {
+		err = ld.reg.load(ld.src, texts, true)
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	return nil
+}
+
+// Watch reloads the file when it's written, or the process gets SIGHUP,
+// until ctx is done. With a zero interval, Watch uses inotify where
+// available and otherwise polls the file's modification time and size every
+// second. The returned channel receives reload errors, dropping those that
+// would block, and is closed once ctx is done. Load the file before Watch to
+// apply its current version.
+func (ld *Loader) Watch(ctx context.Context, interval time.Duration) <-chan error {
+	errs := make(chan error, 1)
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	var changed <-chan struct{}
+	if interval == 0 {
+		var err error
+		if changed, err = notify(ctx, ld.src.Name); err != nil {
+			interval = time.Second
+		}
+	}
+	if interval > 0 {
+		changed = poll(ctx, ld.src.Name, interval)
+	}
+	go func() {
+		defer close(errs)
+		defer signal.Stop(hup)
+		for {
+			select {
+			case <-hup:
+			case _, ok := <-changed:
+				if !ok {
+					if ctx.Err() != nil {
+						return
+					}
+					changed = poll(ctx, ld.src.Name, time.Second)
+					continue
+				}
+			case <-ctx.Done():
+				return
+			}
+			if err := ld.Load(); err != nil {
+				select {
+				case errs <- err:
+				default:
+				}
+			}
+		}
+	}()
+	return errs
+}
+
+// poll returns a channel that receives after each change of the file's
+// modification time or size, and is closed once ctx is done.
+func poll(ctx context.Context, path string, interval time.Duration) <-chan struct{} {
+	changed := make(chan struct{}, 1)
+	last := stat(path)
+	go func() {
+		defer close(changed)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+			case <-ctx.Done():
+				return
+			}
+			if now := stat(path); now != last {
+				last = now
+				signal1(changed)
+			}
+		}
+	}()
+	return changed
+}
+
+// signal1 sends to a buffered channel unless it already has a pending
+// signal.
+func signal1(ch chan<- struct{}) {
+	select {
+	case ch <- struct{}{}:
+	default:
+	}
+}
+
+func stat(path string) stamp {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return stamp{}
+	}
+	return stamp{fi.ModTime(), fi.Size()}
+}
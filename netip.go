@@ -5,13 +5,14 @@
 package opt
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/netip"
-	"unsafe"
+	"strings"
 )
 
-type NetIP[T netip.Addr | netip.AddrPort | netip.Prefix] struct{ v T }
+type NetIP[T netip.Addr | netip.AddrPort | netip.Prefix] struct{ v value[T] }
 
 type Addr = NetIP[netip.Addr]
 type AddrPort = NetIP[netip.AddrPort]
@@ -22,7 +23,7 @@ func MustParseAddr(s string) *NetIP[netip.Addr] {
 	if err != nil {
 		panic(err)
 	}
-	return &Addr{v}
+	return &Addr{newValue(v)}
 }
 
 func MustParseAddrPort(s string) *NetIP[netip.AddrPort] {
@@ -30,7 +31,7 @@ func MustParseAddrPort(s string) *NetIP[netip.AddrPort] {
 	if err != nil {
 		panic(err)
 	}
-	return &AddrPort{v}
+	return &AddrPort{newValue(v)}
 }
 
 func MustParsePrefix(s string) *NetIP[netip.Prefix] {
@@ -38,7 +39,24 @@ func MustParsePrefix(s string) *NetIP[netip.Prefix] {
 	if err != nil {
 		panic(err)
 	}
-	return &Prefix{v}
+	return &Prefix{newValue(v)}
+}
+
+func (opt *NetIP[T]) box() *box { return opt.v.box() }
+
+func (opt *NetIP[T]) cell() *value[T] { return &opt.v }
+
+// isZero reports whether the value is the zero T, whose String, e.g.
+// "invalid IP", doesn't parse; see textof.
+func (opt *NetIP[T]) isZero() bool {
+	var zero T
+	return opt.v.load() == zero
+}
+
+func (opt *NetIP[T]) check(T) error { return nil }
+
+func (opt *NetIP[T]) Default() T {
+	return opt.v.state().layers[Default]
 }
 
 func (opt NetIP[T]) Format(f fmt.State, verb rune) {
@@ -46,6 +64,10 @@ func (opt NetIP[T]) Format(f fmt.State, verb rune) {
 	fmt.Fprintf(f, format, opt.String())
 }
 
+func (opt *NetIP[T]) IsDefault() bool {
+	return opt.v.state().top == Default
+}
+
 func (opt NetIP[T]) MarshalJSON() ([]byte, error) {
 	return json.Marshal(opt.String())
 }
@@ -58,14 +80,45 @@ func (opt NetIP[T]) MarshalYAML() (interface{}, error) {
 	return opt.String(), nil
 }
 
+func (opt *NetIP[T]) parse(s string) (T, error) {
+	var v T
+	err := textunmarshaler(&v)([]byte(s))
+	return v, err
+}
+
+func (opt *NetIP[T]) reset() write { return reset[T](opt) }
+
+func (opt *NetIP[T]) Reset() {
+	commit(opt.reset())
+}
+
+func (opt *NetIP[T]) schema() map[string]any {
+	var v T
+	return ipSchema(v)
+}
+
 func (opt *NetIP[T]) Set(s string) error {
 	return opt.UnmarshalText([]byte(s))
 }
 
+func (opt *NetIP[T]) settings() []Setting {
+	return opt.v.state().settings(opt)
+}
+
+func (opt *NetIP[T]) Source() Source {
+	return opt.v.state().source()
+}
+
+func (opt *NetIP[T]) stage(src Source, v any) (write, error) {
+	return stage[T](opt, src, v)
+}
+
+func (opt *NetIP[T]) Store(v T) error {
+	return store[T](opt, v)
+}
+
 func (opt NetIP[T]) String() string {
-	mutex.RLock()
-	defer mutex.RUnlock()
-	return fmt.Sprint(opt.v)
+	return fmt.Sprint(opt.Value())
 }
 
 func (opt *NetIP[T]) UnmarshalJSON(text []byte) error {
@@ -80,61 +133,135 @@ func (opt *NetIP[T]) UnmarshalJSON(text []byte) error {
 }
 
 func (opt *NetIP[T]) UnmarshalText(text []byte) error {
-	var v T
-	if err := textunmarshaler(&v)(text); err != nil {
+	v, err := opt.parse(string(text))
+	if err != nil {
 		return err
 	}
-	mutex.Lock()
-	defer mutex.Unlock()
-	opt.v = v
-	publish(uintptr(unsafe.Pointer(opt)))
-	return nil
+	return opt.Store(v)
+}
+
+func (opt *NetIP[T]) Unset(l Layer) error {
+	return apply(opt, Source{Layer: l}, nil)
+}
+
+func (opt *NetIP[T]) Validate(fns ...func(T) error) *NetIP[T] {
+	opt.v.validate(fns)
+	return opt
 }
 
 func (opt NetIP[T]) Value() T {
-	mutex.RLock()
-	defer mutex.RUnlock()
-	return opt.v
+	return opt.v.load()
 }
 
-type NetIPs[T netip.Addr | netip.AddrPort | netip.Prefix] struct{ v []T }
+func (opt *NetIP[T]) Watch(ctx context.Context) <-chan T {
+	return watch(ctx, opt, opt.Value)
+}
+
+type NetIPs[T netip.Addr | netip.AddrPort | netip.Prefix] struct{ v value[[]T] }
 
 type Addrs = NetIPs[netip.Addr]
 type AddrPorts = NetIPs[netip.AddrPort]
 type Prefixes = NetIPs[netip.Prefix]
 
 func NewAddrs(v []netip.Addr) *NetIPs[netip.Addr] {
-	return &NetIPs[netip.Addr]{v}
+	return &NetIPs[netip.Addr]{newValue(v)}
 }
 
 func NewAddrPorts(v []netip.AddrPort) *NetIPs[netip.AddrPort] {
-	return &NetIPs[netip.AddrPort]{v}
+	return &NetIPs[netip.AddrPort]{newValue(v)}
 }
 
 func NewPrefixes(v []netip.Prefix) *NetIPs[netip.Prefix] {
-	return &NetIPs[netip.Prefix]{v}
+	return &NetIPs[netip.Prefix]{newValue(v)}
+}
+
+func (opt *NetIPs[T]) box() *box { return opt.v.box() }
+
+func (opt *NetIPs[T]) cell() *value[[]T] { return &opt.v }
+
+func (opt *NetIPs[T]) check([]T) error { return nil }
+
+func (opt *NetIPs[T]) Default() []T {
+	return opt.v.state().layers[Default]
+}
+
+func (opt *NetIPs[T]) IsDefault() bool {
+	return opt.v.state().top == Default
 }
 
 func (opt NetIPs[T]) MarshalJSON() ([]byte, error) {
-	mutex.RLock()
-	defer mutex.RUnlock()
-	ses := make([]string, len(opt.v))
-	for i, v := range opt.v {
+	vs := opt.Value()
+	ses := make([]string, len(vs))
+	for i, v := range vs {
 		ses[i] = fmt.Sprint(v)
 	}
 	return json.Marshal(ses)
 }
 
+func (opt NetIPs[T]) MarshalText() ([]byte, error) {
+	return []byte(opt.String()), nil
+}
+
 func (opt NetIPs[T]) MarshalYAML() (interface{}, error) {
 	return opt.Value(), nil
 }
 
+func (opt *NetIPs[T]) parse(s string) ([]T, error) {
+	l, err := list(s)
+	if err != nil {
+		return nil, err
+	}
+	vs := make([]T, len(l))
+	for i, s := range l {
+		if err = textunmarshaler(&vs[i])([]byte(s)); err != nil {
+			return nil, err
+		}
+	}
+	return vs, nil
+}
+
+func (opt *NetIPs[T]) reset() write { return reset[[]T](opt) }
+
+func (opt *NetIPs[T]) Reset() {
+	commit(opt.reset())
+}
+
+func (opt *NetIPs[T]) schema() map[string]any {
+	var v T
+	return map[string]any{"type": "array", "items": ipSchema(v)}
+}
+
+func (opt *NetIPs[T]) Set(s string) error {
+	vs, err := opt.parse(s)
+	if err != nil {
+		return err
+	}
+	return opt.Store(vs)
+}
+
+func (opt *NetIPs[T]) settings() []Setting {
+	return opt.v.state().settings(opt)
+}
+
+func (opt *NetIPs[T]) Source() Source {
+	return opt.v.state().source()
+}
+
+func (opt *NetIPs[T]) stage(src Source, v any) (write, error) {
+	return stage[[]T](opt, src, v)
+}
+
 func (opt *NetIPs[T]) Store(v []T) error {
-	mutex.Lock()
-	defer mutex.Unlock()
-	opt.v = v
-	publish(uintptr(unsafe.Pointer(opt)))
-	return nil
+	return store[[]T](opt, v)
+}
+
+func (opt NetIPs[T]) String() string {
+	vs := opt.Value()
+	ses := make([]string, len(vs))
+	for i, v := range vs {
+		ses[i] = fmt.Sprint(v)
+	}
+	return "[" + strings.Join(ses, " ") + "]"
 }
 
 func (opt *NetIPs[T]) UnmarshalTOML(input interface{}) error {
@@ -169,8 +296,19 @@ func (opt *NetIPs[T]) UnmarshalYAML(unmarshal func(interface{}) error) error {
 	return opt.Store(vs)
 }
 
+func (opt *NetIPs[T]) Unset(l Layer) error {
+	return apply(opt, Source{Layer: l}, nil)
+}
+
+func (opt *NetIPs[T]) Validate(fns ...func([]T) error) *NetIPs[T] {
+	opt.v.validate(fns)
+	return opt
+}
+
 func (opt NetIPs[T]) Value() []T {
-	mutex.RLock()
-	defer mutex.RUnlock()
-	return opt.v
+	return opt.v.load()
+}
+
+func (opt *NetIPs[T]) Watch(ctx context.Context) <-chan []T {
+	return watch(ctx, opt, opt.Value)
 }
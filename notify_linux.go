@@ -0,0 +1,63 @@
+// Copyright © 2021-2022 Platina Systems, Inc. All rights reserved.
+// Use of this source code is governed by the GPL-2 license described in the
+// LICENSE file.
+
+//go:build linux
+
+package opt
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"unsafe"
+)
+
+// notify returns a channel that receives after the file is written or
+// renamed into place, as seen by inotify of its directory. The channel is
+// closed once ctx is done or reading inotify fails.
+func notify(ctx context.Context, path string) (<-chan struct{}, error) {
+	fd, err := syscall.InotifyInit1(syscall.IN_CLOEXEC | syscall.IN_NONBLOCK)
+	if err != nil {
+		return nil, err
+	}
+	dir, name := filepath.Split(filepath.Clean(path))
+	if len(dir) == 0 {
+		dir = "."
+	}
+	_, err = syscall.InotifyAddWatch(fd, dir,
+		syscall.IN_CLOSE_WRITE|syscall.IN_MOVED_TO)
+	if err != nil {
+		syscall.Close(fd)
+		return nil, err
+	}
+	// a non-blocking file uses the runtime poller so Close interrupts Read
+	f := os.NewFile(uintptr(fd), "inotify")
+	go func() {
+		<-ctx.Done()
+		f.Close()
+	}()
+	changed := make(chan struct{}, 1)
+	go func() {
+		defer close(changed)
+		buf := make([]byte, 64*(syscall.SizeofInotifyEvent+syscall.NAME_MAX+1))
+		for {
+			n, err := f.Read(buf)
+			if err != nil {
+				return
+			}
+			for i := 0; i+syscall.SizeofInotifyEvent <= n; {
+				ev := (*syscall.InotifyEvent)(unsafe.Pointer(&buf[i]))
+				i += syscall.SizeofInotifyEvent
+				s := string(buf[i : i+int(ev.Len)])
+				i += int(ev.Len)
+				if strings.TrimRight(s, "\x00") == name {
+					signal1(changed)
+				}
+			}
+		}
+	}()
+	return changed, nil
+}
@@ -0,0 +1,17 @@
+// Copyright © 2021-2022 Platina Systems, Inc. All rights reserved.
+// Use of this source code is governed by the GPL-2 license described in the
+// LICENSE file.
+
+//go:build !linux
+
+package opt
+
+import (
+	"context"
+	"errors"
+)
+
+// notify isn't available, so Loader polls instead.
+func notify(context.Context, string) (<-chan struct{}, error) {
+	return nil, errors.New("inotify unavailable")
+}
@@ -5,10 +5,10 @@
 package opt
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"strings"
-	"unsafe"
 )
 
 type Numeric interface {
@@ -17,14 +17,41 @@ type Numeric interface {
 		~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64 | ~uintptr
 }
 
-type Number[T Numeric] struct{ v, min, max T }
+type Number[T Numeric] struct {
+	v        value[T]
+	min, max T
+}
 
 func LimitedNumber[T Numeric](v, min, max T) *Number[T] {
-	return &Number[T]{v, min, max}
+	return &Number[T]{newValue(v), min, max}
 }
 
 func NewNumber[T Numeric](v T) *Number[T] {
-	return &Number[T]{v: v}
+	return &Number[T]{v: newValue(v)}
+}
+
+func (opt *Number[T]) box() *box { return opt.v.box() }
+
+func (opt *Number[T]) cell() *value[T] { return &opt.v }
+
+func (opt *Number[T]) check(v T) error {
+	if opt.min != opt.max {
+		if v < opt.min {
+			return fmt.Errorf("%v < min{%v}", v, opt.min)
+		}
+		if v > opt.max {
+			return fmt.Errorf("%v > max{%v}", v, opt.max)
+		}
+	}
+	return nil
+}
+
+func (opt *Number[T]) Default() T {
+	return opt.v.state().layers[Default]
+}
+
+func (opt *Number[T]) IsDefault() bool {
+	return opt.v.state().top == Default
 }
 
 func (opt Number[T]) MarshalJSON() ([]byte, error) {
@@ -40,29 +67,48 @@ func (opt Number[T]) MarshalYAML() (interface{}, error) {
 	return opt.Value(), nil
 }
 
-func (opt *Number[T]) Set(s string) error {
+func (opt *Number[T]) parse(s string) (T, error) {
 	var v T
 	_, err := fmt.Sscan(s, &v)
+	return v, err
+}
+
+func (opt *Number[T]) reset() write { return reset[T](opt) }
+
+func (opt *Number[T]) Reset() {
+	commit(opt.reset())
+}
+
+func (opt *Number[T]) schema() map[string]any {
+	s := numberSchema[T]()
+	if opt.min != opt.max {
+		s["minimum"], s["maximum"] = opt.min, opt.max
+	}
+	return s
+}
+
+func (opt *Number[T]) Set(s string) error {
+	v, err := opt.parse(s)
 	if err != nil {
 		return err
 	}
 	return opt.Store(v)
 }
 
+func (opt *Number[T]) settings() []Setting {
+	return opt.v.state().settings(opt)
+}
+
+func (opt *Number[T]) Source() Source {
+	return opt.v.state().source()
+}
+
+func (opt *Number[T]) stage(src Source, v any) (write, error) {
+	return stage[T](opt, src, v)
+}
+
 func (opt *Number[T]) Store(v T) error {
-	if opt.min != opt.max {
-		if v < opt.min {
-			return fmt.Errorf("%v < min{%v}", v, opt.min)
-		}
-		if v > opt.min {
-			return fmt.Errorf("%v > max{%v}", v, opt.max)
-		}
-	}
-	mutex.Lock()
-	defer mutex.Unlock()
-	opt.v = v
-	publish(uintptr(unsafe.Pointer(opt)))
-	return nil
+	return store[T](opt, v)
 }
 
 func (opt Number[T]) String() string {
@@ -81,23 +127,47 @@ func (opt *Number[T]) UnmarshalText(text []byte) error {
 	return opt.Set(string(text))
 }
 
+func (opt *Number[T]) Unset(l Layer) error {
+	return apply(opt, Source{Layer: l}, nil)
+}
+
+func (opt *Number[T]) Validate(fns ...func(T) error) *Number[T] {
+	opt.v.validate(fns)
+	return opt
+}
+
 func (opt Number[T]) Value() T {
-	mutex.RLock()
-	defer mutex.RUnlock()
-	return opt.v
+	return opt.v.load()
+}
+
+func (opt *Number[T]) Watch(ctx context.Context) <-chan T {
+	return watch(ctx, opt, opt.Value)
 }
 
-type Numbers[T Numeric] struct{ v []T }
+type Numbers[T Numeric] struct{ v value[[]T] }
 
 func NewNumbers[T Numeric](v []T) *Numbers[T] {
-	return &Numbers[T]{v}
+	return &Numbers[T]{newValue(v)}
+}
+
+func (opt *Numbers[T]) box() *box { return opt.v.box() }
+
+func (opt *Numbers[T]) cell() *value[[]T] { return &opt.v }
+
+func (opt *Numbers[T]) check([]T) error { return nil }
+
+func (opt *Numbers[T]) Default() []T {
+	return opt.v.state().layers[Default]
+}
+
+func (opt *Numbers[T]) IsDefault() bool {
+	return opt.v.state().top == Default
 }
 
 func (opt Numbers[T]) MarshalJSON() ([]byte, error) {
-	mutex.RLock()
-	defer mutex.RUnlock()
-	f := make([]float64, len(opt.v))
-	for i, n := range opt.v {
+	v := opt.Value()
+	f := make([]float64, len(v))
+	for i, n := range v {
 		f[i] = float64(n)
 	}
 	return json.Marshal(f)
@@ -111,20 +181,59 @@ func (opt Numbers[T]) MarshalYAML() (interface{}, error) {
 	return opt.Value(), nil
 }
 
+func (opt *Numbers[T]) parse(s string) ([]T, error) {
+	l, err := list(s)
+	if err != nil {
+		return nil, err
+	}
+	v := make([]T, len(l))
+	for i, s := range l {
+		if _, err = fmt.Sscan(s, &v[i]); err != nil {
+			return nil, err
+		}
+	}
+	return v, nil
+}
+
+func (opt *Numbers[T]) reset() write { return reset[[]T](opt) }
+
+func (opt *Numbers[T]) Reset() {
+	commit(opt.reset())
+}
+
+func (opt *Numbers[T]) schema() map[string]any {
+	return map[string]any{"type": "array", "items": numberSchema[T]()}
+}
+
+func (opt *Numbers[T]) Set(s string) error {
+	v, err := opt.parse(s)
+	if err != nil {
+		return err
+	}
+	return opt.Store(v)
+}
+
+func (opt *Numbers[T]) settings() []Setting {
+	return opt.v.state().settings(opt)
+}
+
+func (opt *Numbers[T]) Source() Source {
+	return opt.v.state().source()
+}
+
+func (opt *Numbers[T]) stage(src Source, v any) (write, error) {
+	return stage[[]T](opt, src, v)
+}
+
 func (opt *Numbers[T]) Store(v []T) error {
-	mutex.Lock()
-	defer mutex.Unlock()
-	opt.v = v
-	publish(uintptr(unsafe.Pointer(opt)))
-	return nil
+	return store[[]T](opt, v)
 }
 
 func (opt Numbers[T]) String() string {
+	v := opt.Value()
 	sb := new(strings.Builder)
-	mutex.RLock()
-	defer mutex.RUnlock()
 	fmt.Fprint(sb, "[")
-	for i, n := range opt.v {
+	for i, n := range v {
 		if i > 0 {
 			fmt.Fprint(sb, ", ")
 		}
@@ -161,8 +270,19 @@ func (opt *Numbers[T]) UnmarshalYAML(unmarshal func(interface{}) error) error {
 	return opt.Store(v)
 }
 
+func (opt *Numbers[T]) Unset(l Layer) error {
+	return apply(opt, Source{Layer: l}, nil)
+}
+
+func (opt *Numbers[T]) Validate(fns ...func([]T) error) *Numbers[T] {
+	opt.v.validate(fns)
+	return opt
+}
+
 func (opt Numbers[T]) Value() []T {
-	mutex.RLock()
-	defer mutex.RUnlock()
-	return opt.v
+	return opt.v.load()
+}
+
+func (opt *Numbers[T]) Watch(ctx context.Context) <-chan []T {
+	return watch(ctx, opt, opt.Value)
 }
@@ -7,40 +7,202 @@ package opt
 
 import (
 	"encoding"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
+	"unicode"
 )
 
+// mutex serializes writers, each of which bumps seq; readers atomically load
+// an option's own value without it.
 var (
-	mutex sync.RWMutex
-	subs  []chan<- uintptr
+	mutex sync.Mutex
+	seq   uint64
 )
 
-// Subscribe to option change notifications.
-func Subscribe(ch chan<- uintptr) {
-	mutex.Lock()
-	defer mutex.Unlock()
-	subs = append(subs, ch)
+// Change describes the update of an option.
+type Change struct {
+	// Seq increases by one with each change.
+	Seq  uint64
+	Time time.Time
+	// Name is the option's registered path, or empty if unregistered.
+	Name string
+	Opt  Option
+	// Old and New are the option's typed values, e.g. time.Duration.
+	Old, New any
+	// Source of the new value.
+	Source Source
+	// Group has the changes of each option committed by a Tx instead of
+	// the above Name, Opt, Old and New.
+	Group []Change
+}
+
+// Changes returns the Group, or else this single change.
+func (c Change) Changes() []Change {
+	if len(c.Group) > 0 {
+		return c.Group
+	}
+	return []Change{c}
 }
 
-// Unsubscribe to option change notifications.
-func Unsubscribe(ch chan<- uintptr) {
+// NewText returns the text form of the new value.
+func (c Change) NewText() string { return text(c.New) }
+
+// OldText returns the text form of the old value.
+func (c Change) OldText() string { return text(c.Old) }
+
+func (c Change) String() string {
+	if len(c.Group) > 0 {
+		ses := make([]string, len(c.Group))
+		for i, g := range c.Group {
+			ses[i] = g.String()
+		}
+		return strings.Join(ses, "\n")
+	}
+	name := c.Name
+	if len(name) == 0 {
+		name = fmt.Sprintf("%p", c.Opt)
+	}
+	return fmt.Sprintf("%s: %s -> %s", name, c.OldText(), c.NewText())
+}
+
+// commit the writes under one lock acquisition and publish the changes of
+// those that aren't below another layer, merging those of the same option.
+func commit(ws ...write) {
+	if len(ws) == 0 {
+		return
+	}
 	mutex.Lock()
 	defer mutex.Unlock()
-	for i, sub := range subs {
-		if ch == sub {
-			copy(subs[i:], subs[i+1:])
-			subs = subs[:len(subs)-1]
-			break
+	cs := make([]Change, 0, len(ws))
+	var index map[stager]int
+	if len(ws) > 1 {
+		index = make(map[stager]int, len(ws))
+	}
+	now := time.Now()
+	atomic.AddUint64(&version, 1)
+	for _, w := range ws {
+		old, new, changed := w.swap(now)
+		if !changed {
+			continue
+		}
+		if i, found := index[w.opt]; found {
+			cs[i].New = new
+			cs[i].Source = w.opt.Source()
+			continue
 		}
+		if index != nil {
+			index[w.opt] = len(cs)
+		}
+		cs = append(cs, Change{
+			Opt:    w.opt,
+			Old:    old,
+			New:    new,
+			Source: w.opt.Source(),
+		})
+	}
+	atomic.AddUint64(&version, 1)
+	if len(cs) > 0 {
+		publish(now, cs...)
 	}
 }
 
-func publish(ptr uintptr) {
-	for _, sub := range subs {
-		sub <- ptr
+// publish changes, while holding the write lock, as one event with a Group
+// of more than one.
+func publish(now time.Time, cs ...Change) {
+	seq++
+	for i := range cs {
+		cs[i].Seq = seq
+		cs[i].Time = now
+		cs[i].Name = nameof(cs[i].Opt)
 	}
+	c := cs[0]
+	if len(cs) > 1 {
+		c = Change{Seq: seq, Time: now, Group: cs}
+	}
+	audit(c)
+	dispatch(c)
+}
+
+// text formats typed values like the String method of their option.
+func text(v any) string {
+	switch t := v.(type) {
+	case url.URL:
+		return t.String()
+	case time.Time:
+		if b, err := t.MarshalText(); err == nil {
+			return string(b)
+		}
+	}
+	return fmt.Sprint(v)
+}
+
+// textof returns the text form of opt that parses as its value: that of
+// String, except empty for a zero NetIP, whose String, e.g. "invalid IP",
+// doesn't parse.
+func textof(opt Option) string {
+	if isZero(opt) {
+		return ""
+	}
+	return opt.String()
+}
+
+// isZero reports whether opt is a zero NetIP.
+func isZero(opt Option) bool {
+	z, ok := opt.(interface{ isZero() bool })
+	return ok && z.isZero()
 }
 
 func textunmarshaler(v any) func([]byte) error {
 	return v.(encoding.TextUnmarshaler).UnmarshalText
 }
+
+// quote list elements that list would otherwise split or unescape.
+func quote(s string) string {
+	q := strconv.Quote(s)
+	if len(s) == 0 || q[1:len(q)-1] != s || strings.ContainsAny(s, ",[]") ||
+		strings.IndexFunc(s, unicode.IsSpace) >= 0 {
+		return q
+	}
+	return s
+}
+
+// list splits the text form of a slice option, "[a, b]", "a b" or "a,b", into
+// its elements; double quoted elements may contain separators.
+func list(s string) ([]string, error) {
+	s = strings.TrimSpace(s)
+	if strings.HasPrefix(s, "[") && strings.HasSuffix(s, "]") {
+		s = s[1 : len(s)-1]
+	}
+	var l []string
+	for {
+		s = strings.TrimLeftFunc(s, func(r rune) bool {
+			return r == ',' || unicode.IsSpace(r)
+		})
+		if len(s) == 0 {
+			return l, nil
+		}
+		if s[0] == '"' {
+			q, err := strconv.QuotedPrefix(s)
+			if err != nil {
+				return nil, fmt.Errorf("%s invalid", s)
+			}
+			uq, _ := strconv.Unquote(q)
+			l = append(l, uq)
+			s = s[len(q):]
+			continue
+		}
+		end := strings.IndexFunc(s, func(r rune) bool {
+			return r == ',' || unicode.IsSpace(r)
+		})
+		if end < 0 {
+			end = len(s)
+		}
+		l = append(l, s[:end])
+		s = s[end:]
+	}
+}
@@ -0,0 +1,205 @@
+// Copyright © 2021-2022 Platina Systems, Inc. All rights reserved.
+// Use of this source code is governed by the GPL-2 license described in the
+// LICENSE file.
+
+package opt
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"unsafe"
+)
+
+// ErrNotFound is wrapped by Registry errors for unregistered names.
+var ErrNotFound = errors.New("not found")
+
+// Option is the flag.Value implemented by every option pointer.
+type Option interface {
+	Set(string) error
+	String() string
+}
+
+// Registry associates dotted path names, like "scalar.addr_port", with
+// options. The zero value is an empty registry ready to use.
+type Registry struct {
+	mutex sync.RWMutex
+	opts  map[string]*entry
+}
+
+// entry is a registered option with the attributes of its `opt` field tag.
+type entry struct {
+	opt    Option
+	env    string
+	flag   string
+	usage  string
+	expand bool
+}
+
+// Get returns the text form of the named option.
+func (reg *Registry) Get(name string) (string, error) {
+	opt, err := reg.lookup(name)
+	if err != nil {
+		return "", err
+	}
+	return textof(opt), nil
+}
+
+// List returns the sorted names of registered options. With prefixes, list
+// only those names equal to or within one of the given paths.
+func (reg *Registry) List(prefixes ...string) []string {
+	reg.mutex.RLock()
+	defer reg.mutex.RUnlock()
+	names := make([]string, 0, len(reg.opts))
+	for name := range reg.opts {
+		if len(prefixes) == 0 || within(name, prefixes...) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Lookup returns the named option for type assertion by the caller, e.g.
+//
+//	d, ok := reg.Lookup("timeout").(*opt.Duration)
+func (reg *Registry) Lookup(name string) Option {
+	if e := reg.entry(name); e != nil {
+		return e.opt
+	}
+	return nil
+}
+
+// Register option with the given dotted path name. The option must be a
+// comparable type, such as a pointer, since it's also used as a key, and
+// this package's options have one name, so Unregister one to rename it. Any
+// other flag.Value has no layers, so only Set changes it; Clear, Reset and
+// Load skip it, unless a file has its value, which is invalid.
+func (reg *Registry) Register(name string, opt Option) error {
+	return reg.register(name, &entry{opt: opt})
+}
+
+// Set the Runtime layer of the named option from its text form.
+func (reg *Registry) Set(name, s string) error {
+	opt, err := reg.lookup(name)
+	if err != nil {
+		return err
+	}
+	if s, err = reg.text(name, s, nil); err != nil {
+		return err
+	}
+	return opt.Set(s)
+}
+
+// Visit each registered option in name order until fn returns an error.
+func (reg *Registry) Visit(fn func(name string, opt Option) error) error {
+	for _, name := range reg.List() {
+		if opt := reg.Lookup(name); opt != nil {
+			if err := fn(name, opt); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (reg *Registry) entry(name string) *entry {
+	reg.mutex.RLock()
+	defer reg.mutex.RUnlock()
+	return reg.opts[name]
+}
+
+func (reg *Registry) lookup(name string) (Option, error) {
+	if opt := reg.Lookup(name); opt != nil {
+		return opt, nil
+	}
+	return nil, fmt.Errorf("%q %w", name, ErrNotFound)
+}
+
+func (reg *Registry) register(name string, e *entry) error {
+	if len(name) == 0 || strings.HasPrefix(name, ".") ||
+		strings.HasSuffix(name, ".") || strings.Contains(name, "..") {
+		return fmt.Errorf("%q invalid", name)
+	}
+	// options are keyed by value in histories
+	if e.opt == nil || !reflect.TypeOf(e.opt).Comparable() {
+		return fmt.Errorf("%s: %T invalid", name, e.opt)
+	}
+	reg.mutex.Lock()
+	defer reg.mutex.Unlock()
+	if _, found := reg.opts[name]; found {
+		return fmt.Errorf("%q duplicate", name)
+	}
+	if flag := e.flagname(name); len(flag) > 0 {
+		for path, other := range reg.opts {
+			if other.flagname(path) == flag {
+				return fmt.Errorf("%s: -%s duplicate", name, flag)
+			}
+		}
+	}
+	if st, ok := e.opt.(stager); ok {
+		// an option has one name, kept in its box, which this also
+		// allocates for a zero option before it's shared
+		path := name
+		b := st.box()
+		if !atomic.CompareAndSwapPointer(&b.name, nil, unsafe.Pointer(&path)) {
+			return fmt.Errorf("%s: %T registered as %q", name, e.opt,
+				*(*string)(atomic.LoadPointer(&b.name)))
+		}
+	}
+	if reg.opts == nil {
+		reg.opts = make(map[string]*entry)
+	}
+	reg.opts[name] = e
+	return nil
+}
+
+// flagname returns the name of the entry's flag, or empty if it has none.
+func (e *entry) flagname(path string) string {
+	switch e.flag {
+	case "-":
+		return ""
+	case "":
+		return path
+	}
+	return e.flag
+}
+
+// Unregister the named option so that it may be registered again, e.g. by
+// another registry.
+func (reg *Registry) Unregister(name string) error {
+	reg.mutex.Lock()
+	defer reg.mutex.Unlock()
+	e, found := reg.opts[name]
+	if !found {
+		return fmt.Errorf("%q %w", name, ErrNotFound)
+	}
+	delete(reg.opts, name)
+	if st, ok := e.opt.(stager); ok {
+		atomic.StorePointer(&st.box().name, nil)
+	}
+	return nil
+}
+
+// nameof returns the registered name of opt, or empty if it's unregistered.
+func nameof(opt Option) string {
+	if st, ok := opt.(stager); ok {
+		if p := atomic.LoadPointer(&st.box().name); p != nil {
+			return *(*string)(p)
+		}
+	}
+	return ""
+}
+
+func within(name string, prefixes ...string) bool {
+	for _, prefix := range prefixes {
+		if name == prefix || strings.HasPrefix(name, prefix+".") {
+			return true
+		}
+	}
+	return false
+}
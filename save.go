@@ -0,0 +1,166 @@
+// Copyright © 2021-2022 Platina Systems, Inc. All rights reserved.
+// Use of this source code is governed by the GPL-2 license described in the
+// LICENSE file.
+
+package opt
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v2"
+)
+
+// Save the value of each registered option, or with overrides, only those
+// that aren't the Default, to a "toml", "yaml" or "json" file; if format is
+// empty, that of the path's extension. The file, or the target of a
+// symbolic link, is replaced atomically by renaming a temporary file, and
+// keeps its permissions and, where permitted, its owner.
+func (reg *Registry) Save(path, format string, overrides bool) error {
+	if len(format) == 0 {
+		format = formatof(path)
+	}
+	var names []string
+	for _, name := range reg.List() {
+		if overrides {
+			if st, ok := reg.Lookup(name).(stager); ok && st.IsDefault() {
+				continue
+			}
+		}
+		names = append(names, name)
+	}
+	b, err := reg.encode(format, names)
+	if err == nil {
+		err = writeFile(path, b)
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	return nil
+}
+
+// encode the named options as a tree in the given format.
+func (reg *Registry) encode(format string, names []string) ([]byte, error) {
+	switch format {
+	case "json":
+		tree, err := reg.tree("", names, rawJSON)
+		if err != nil {
+			return nil, err
+		}
+		b, err := json.MarshalIndent(tree, "", "\t")
+		return append(b, '\n'), err
+	case "toml", "yaml":
+		tree, err := reg.tree("", names, func(opt Option) (any, error) {
+			if m, ok := opt.(yaml.Marshaler); ok && !isZero(opt) {
+				return m.MarshalYAML()
+			}
+			return textof(opt), nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		if format == "yaml" {
+			return yaml.Marshal(tree)
+		}
+		buf := new(bytes.Buffer)
+		err = toml.NewEncoder(buf).Encode(tree)
+		return buf.Bytes(), err
+	}
+	return nil, fmt.Errorf("%q format unknown", format)
+}
+
+// tree nests the leaf of each named option within the path by the rest of
+// its name; tables indexed from 0, like "slice.structs.0", become lists.
+func (reg *Registry) tree(path string, names []string,
+	leaf func(Option) (any, error)) (map[string]any, error) {
+	tree := make(map[string]any)
+	for _, name := range names {
+		opt := reg.Lookup(name)
+		if opt == nil {
+			continue
+		}
+		v, err := leaf(opt)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		if len(path) > 0 {
+			name = strings.TrimPrefix(name, path+".")
+		}
+		m := tree
+		keys := strings.Split(name, ".")
+		for _, k := range keys[:len(keys)-1] {
+			sub, ok := m[k].(map[string]any)
+			if !ok {
+				sub = make(map[string]any)
+				m[k] = sub
+			}
+			m = sub
+		}
+		m[keys[len(keys)-1]] = v
+	}
+	return lists(tree).(map[string]any), nil
+}
+
+// lists replaces each table of a tree that's indexed from 0 with a list.
+func lists(v any) any {
+	m, ok := v.(map[string]any)
+	if !ok {
+		return v
+	}
+	for k, sub := range m {
+		m[k] = lists(sub)
+	}
+	l := make([]any, len(m))
+	for k, sub := range m {
+		i, err := strconv.Atoi(k)
+		if err != nil || i < 0 || i >= len(l) || l[i] != nil {
+			return m
+		}
+		l[i] = sub
+	}
+	if len(l) == 0 {
+		return m
+	}
+	return l
+}
+
+// writeFile atomically replaces the named file, or that of a symbolic link,
+// keeping its permissions and, where permitted, its owner.
+func writeFile(path string, b []byte) error {
+	if target, err := filepath.EvalSymlinks(path); err == nil {
+		path = target
+	}
+	perm := os.FileMode(0644)
+	fi, err := os.Stat(path)
+	if err == nil {
+		perm = fi.Mode().Perm()
+	}
+	f, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	_, err = f.Write(b)
+	if err == nil {
+		err = f.Chmod(perm)
+	}
+	if err == nil && fi != nil {
+		err = chown(f, fi)
+	}
+	if err == nil {
+		err = f.Sync()
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), path)
+}
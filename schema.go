@@ -0,0 +1,137 @@
+// Copyright © 2021-2022 Platina Systems, Inc. All rights reserved.
+// Use of this source code is governed by the GPL-2 license described in the
+// LICENSE file.
+
+package opt
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/netip"
+	"reflect"
+)
+
+// schemer is implemented by option pointers to describe their values.
+type schemer interface {
+	schema() map[string]any
+}
+
+// durationPattern matches the text of time.ParseDuration.
+const durationPattern = `^[-+]?(0|(([0-9]+(\.[0-9]*)?|\.[0-9]+)(ns|us|µs|μs|ms|s|m|h))+)$`
+
+// Schema returns a JSON Schema of the files that Registry.Load accepts for
+// the struct pointed to by v, after Registry.Bind. Each option is described
+// by its type, limits, aliases and format, and `opt:"usage=..."` tag; the
+// items of a slice of structs are described by its first element, if any,
+// or else by a zero element.
+func Schema(v any) ([]byte, error) {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.Elem().Kind() != reflect.Struct {
+		return nil, fmt.Errorf("%T invalid", v)
+	}
+	doc := schema(rv)
+	doc["$schema"] = "https://json-schema.org/draft/2020-12/schema"
+	return json.MarshalIndent(doc, "", "\t")
+}
+
+// schema describes an option, struct or list of either, or returns nil.
+func schema(rv reflect.Value) map[string]any {
+	if rv.Kind() == reflect.Pointer {
+		if rv.IsNil() {
+			rv = reflect.New(rv.Type().Elem())
+		}
+		rv = rv.Elem()
+	}
+	if !rv.CanAddr() {
+		v := reflect.New(rv.Type()).Elem()
+		v.Set(rv)
+		rv = v
+	}
+	if s, ok := rv.Addr().Interface().(schemer); ok {
+		return s.schema()
+	}
+	switch rv.Kind() {
+	case reflect.Struct:
+		props := make(map[string]any)
+		rt := rv.Type()
+		for i := 0; i < rt.NumField(); i++ {
+			f := rt.Field(i)
+			if !f.IsExported() {
+				continue
+			}
+			name, ok := fieldname(f)
+			if !ok || f.Tag.Get("opt") == "-" {
+				continue
+			}
+			sub := schema(rv.Field(i))
+			if sub == nil {
+				continue
+			}
+			if f.Anonymous && len(name) == 0 && sub["type"] == "object" {
+				for k, v := range sub["properties"].(map[string]any) {
+					props[k] = v
+				}
+				continue
+			}
+			if len(name) == 0 {
+				name = snake(f.Name)
+			}
+			if usage, found := attributes(f.Tag.Get("opt"))["usage"]; found {
+				sub["description"] = usage
+			}
+			props[name] = sub
+		}
+		return map[string]any{
+			"type":                 "object",
+			"properties":           props,
+			"additionalProperties": false,
+		}
+	case reflect.Slice, reflect.Array:
+		elem := reflect.New(rv.Type().Elem()).Elem()
+		if rv.Len() > 0 {
+			elem = rv.Index(0)
+		}
+		if items := schema(elem); items != nil {
+			return map[string]any{"type": "array", "items": items}
+		}
+	}
+	return nil
+}
+
+// ipSchema describes the text of netip.Addr, AddrPort or Prefix.
+func ipSchema(v any) map[string]any {
+	switch v.(type) {
+	case netip.Addr:
+		return map[string]any{
+			"type": "string",
+			"anyOf": []any{
+				map[string]any{"format": "ipv4"},
+				map[string]any{"format": "ipv6"},
+			},
+		}
+	case netip.AddrPort:
+		return map[string]any{
+			"type":    "string",
+			"pattern": `^([0-9.]+|\[[0-9A-Fa-f:.]+(%[^\]]+)?\]):[0-9]{1,5}$`,
+		}
+	case netip.Prefix:
+		return map[string]any{
+			"type":    "string",
+			"pattern": `^[0-9A-Fa-f:.]+/[0-9]{1,3}$`,
+		}
+	}
+	return map[string]any{"type": "string"}
+}
+
+// numberSchema describes numbers of type T.
+func numberSchema[T Numeric]() map[string]any {
+	var v T
+	switch reflect.TypeOf(v).Kind() {
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32,
+		reflect.Int64:
+		return map[string]any{"type": "integer"}
+	}
+	return map[string]any{"type": "integer", "minimum": 0}
+}
@@ -0,0 +1,165 @@
+// Copyright © 2021-2022 Platina Systems, Inc. All rights reserved.
+// Use of this source code is governed by the GPL-2 license described in the
+// LICENSE file.
+
+package opt
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Redacted is the text of a non-empty Secret. Storing it in a Secret keeps
+// that layer's value so that saved files and marshaled snapshots don't
+// clobber it.
+const Redacted = "[redacted]"
+
+// Secret is a password, token or key that's formatted, marshaled, and
+// reported by changes, history and snapshot settings as Redacted unless
+// revealed.
+// The bytes of each value are zeroed once it's replaced or unset.
+type Secret struct{ v value[[]byte] }
+
+func NewSecret(s string) *Secret {
+	return &Secret{newValue([]byte(s))}
+}
+
+func (opt *Secret) box() *box { return opt.v.box() }
+
+func (opt *Secret) cell() *value[[]byte] { return &opt.v }
+
+func (opt *Secret) check([]byte) error { return nil }
+
+func (opt Secret) Format(f fmt.State, verb rune) {
+	fmt.Fprint(f, opt.String())
+}
+
+func (opt *Secret) IsDefault() bool {
+	return opt.v.state().top == Default
+}
+
+func (opt Secret) MarshalJSON() ([]byte, error) {
+	return json.Marshal(opt.String())
+}
+
+func (opt Secret) MarshalText() ([]byte, error) {
+	return []byte(opt.String()), nil
+}
+
+func (opt Secret) MarshalYAML() (interface{}, error) {
+	return opt.String(), nil
+}
+
+func (opt *Secret) parse(s string) ([]byte, error) { return []byte(s), nil }
+
+// redact the values reported by the write and zero those it replaces.
+func (opt *Secret) redact(w write) write {
+	swap := w.swap
+	w.swap = func(now time.Time) (any, any, bool) {
+		prev := opt.v.state().layers
+		_, _, changed := swap(now)
+		cur := opt.v.state().layers
+		for l := range prev {
+			if len(prev[l]) > 0 && (len(cur[l]) == 0 || &prev[l][0] != &cur[l][0]) {
+				wipe(prev[l])
+			}
+		}
+		return Redacted, Redacted, changed
+	}
+	return w
+}
+
+func (opt *Secret) reset() write { return opt.redact(reset[[]byte](opt)) }
+
+func (opt *Secret) Reset() {
+	commit(opt.reset())
+}
+
+// Reveal returns the secret, which is zeroed once it's replaced, so copy it
+// to keep it.
+func (opt *Secret) Reveal() []byte {
+	return opt.v.load()
+}
+
+// reveal returns the settings with a copy of each layer's text instead of
+// Redacted.
+func (opt *Secret) reveal() []Setting {
+	st := opt.v.state()
+	settings := st.settings(opt)
+	for i := range settings {
+		settings[i].Value = string(st.layers[settings[i].Source.Layer])
+	}
+	return settings
+}
+
+func (opt *Secret) schema() map[string]any {
+	return map[string]any{"type": "string", "writeOnly": true}
+}
+
+func (opt *Secret) Set(s string) error {
+	return apply(opt, Source{Layer: Runtime}, s)
+}
+
+func (opt *Secret) settings() []Setting {
+	return opt.v.state().settings(opt)
+}
+
+func (opt *Secret) Source() Source {
+	return opt.v.state().source()
+}
+
+func (opt *Secret) stage(src Source, v any) (write, error) {
+	if v == Redacted {
+		return write{opt, func(time.Time) (any, any, bool) {
+			return Redacted, Redacted, false
+		}}, nil
+	}
+	if b, ok := v.([]byte); ok {
+		v = append([]byte{}, b...)
+	}
+	w, err := stage[[]byte](opt, src, v)
+	if err != nil {
+		return w, err
+	}
+	return opt.redact(w), nil
+}
+
+// Store a copy of v.
+func (opt *Secret) Store(v []byte) error {
+	return apply(opt, Source{Layer: Runtime}, v)
+}
+
+func (opt Secret) String() string {
+	if len(opt.v.load()) == 0 {
+		return ""
+	}
+	return Redacted
+}
+
+func (opt *Secret) UnmarshalJSON(text []byte) error {
+	var s string
+	if err := json.Unmarshal(text, &s); err != nil {
+		return err
+	}
+	return opt.Set(s)
+}
+
+func (opt *Secret) UnmarshalText(text []byte) error {
+	return opt.Set(string(text))
+}
+
+func (opt *Secret) Unset(l Layer) error {
+	return apply(opt, Source{Layer: l}, nil)
+}
+
+func (opt *Secret) Validate(fns ...func([]byte) error) *Secret {
+	opt.v.validate(fns)
+	return opt
+}
+
+func wipe(b []byte) {
+	for i := range b {
+		b[i] = 0
+	}
+}
@@ -0,0 +1,111 @@
+// Copyright © 2021-2022 Platina Systems, Inc. All rights reserved.
+// Use of this source code is governed by the GPL-2 license described in the
+// LICENSE file.
+
+package opt
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Snapshot is an immutable copy of the value and source of each layer of the
+// registered options. It keeps the text of secrets so that Restore rolls
+// them back too, but its JSON form and Settings have them Redacted.
+type Snapshot struct {
+	time     time.Time
+	settings []Setting
+	secrets  map[string]bool
+}
+
+type snapshotJSON struct {
+	Time     time.Time
+	Settings []Setting
+}
+
+// Restore the layers of each option in the snapshot, removing those that
+// weren't set, in one transaction with one notification. If any of its
+// options are unregistered or its values invalid, nothing is changed.
+// Options registered after the snapshot are unchanged.
+func (reg *Registry) Restore(s *Snapshot) error {
+	var names []string
+	byName := make(map[string][]Setting)
+	for _, setting := range s.settings {
+		if _, found := byName[setting.Name]; !found {
+			names = append(names, setting.Name)
+		}
+		byName[setting.Name] = append(byName[setting.Name], setting)
+	}
+	var tx Tx
+	for _, name := range names {
+		opt, err := reg.lookup(name)
+		if err != nil {
+			return err
+		}
+		var set [nLayers]bool
+		for _, setting := range byName[name] {
+			if l := setting.Source.Layer; l < nLayers {
+				set[l] = true
+			}
+			tx.stage(opt, setting.Source, setting.Value)
+		}
+		for l := System; l < nLayers; l++ {
+			if !set[l] {
+				tx.stage(opt, Source{Layer: l}, nil)
+			}
+		}
+	}
+	return tx.Commit()
+}
+
+// Snapshot the layers of the registered options as of the same change.
+func (reg *Registry) Snapshot() *Snapshot {
+	names := reg.List()
+	var settings []Setting
+	secrets := make(map[string]bool)
+	View(func() {
+		settings = settings[:0]
+		for _, name := range names {
+			var layers []Setting
+			switch opt := reg.Lookup(name).(type) {
+			case *Secret:
+				layers = opt.reveal()
+				secrets[name] = true
+			case stager:
+				layers = opt.settings()
+			}
+			for _, setting := range layers {
+				setting.Name = name
+				settings = append(settings, setting)
+			}
+		}
+	})
+	return &Snapshot{time.Now(), settings, secrets}
+}
+
+func (s *Snapshot) MarshalJSON() ([]byte, error) {
+	return json.Marshal(snapshotJSON{s.time, s.Settings()})
+}
+
+// Settings returns a copy of the snapshot's settings by name then layer,
+// with the values of secrets Redacted.
+func (s *Snapshot) Settings() []Setting {
+	settings := append([]Setting(nil), s.settings...)
+	for i, setting := range settings {
+		if s.secrets[setting.Name] && len(setting.Value) > 0 {
+			settings[i].Value = Redacted
+		}
+	}
+	return settings
+}
+
+func (s *Snapshot) Time() time.Time { return s.time }
+
+func (s *Snapshot) UnmarshalJSON(b []byte) error {
+	var t snapshotJSON
+	if err := json.Unmarshal(b, &t); err != nil {
+		return err
+	}
+	s.time, s.settings = t.Time, t.Settings
+	return nil
+}
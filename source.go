@@ -0,0 +1,56 @@
+// Copyright © 2021-2022 Platina Systems, Inc. All rights reserved.
+// Use of this source code is governed by the GPL-2 license described in the
+// LICENSE file.
+
+package opt
+
+import "time"
+
+// Source of an option's value.
+type Source struct {
+	Layer Layer
+	// Name identifies the source within its layer, e.g. the file path and
+	// key, "/etc/app.toml:scalar.int", the environment variable, the flag,
+	// "-listen", or an admin interface; it's empty for plain Set and Store.
+	Name string
+	// Actor is who made the change, e.g. an admin's user name.
+	Actor string `json:",omitempty"`
+	// Time of the store, or zero for constructors.
+	Time time.Time
+}
+
+func (src Source) String() string {
+	s := src.Layer.String()
+	if len(src.Name) > 0 {
+		s += " " + src.Name
+	}
+	if len(src.Actor) > 0 {
+		s += " by " + src.Actor
+	}
+	if !src.Time.IsZero() {
+		s += " at " + src.Time.Format(time.RFC3339)
+	}
+	return s
+}
+
+// Setting is the text form and source of a registered option's value.
+type Setting struct {
+	Name   string
+	Value  string
+	Source Source
+}
+
+// Dump returns the setting of each registered option in name order. Those
+// with a Source.Layer above Default are the values that were overridden.
+func (reg *Registry) Dump() []Setting {
+	var settings []Setting
+	for _, name := range reg.List() {
+		opt := reg.Lookup(name)
+		setting := Setting{Name: name, Value: opt.String()}
+		if st, ok := opt.(stager); ok {
+			setting.Source = st.Source()
+		}
+		settings = append(settings, setting)
+	}
+	return settings
+}
@@ -5,25 +5,49 @@
 package opt
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"strings"
-	"unsafe"
 )
 
 type String[T ~string] struct {
-	v   T
+	v   value[T]
 	aka []T
 }
 
 // Alias returns a string option that assures a new string matches either
 // primary name or one of the aliasws before it's update.
 func Alias[T ~string](name T, aka ...T) *String[T] {
-	return &String[T]{name, append(aka, name)}
+	return &String[T]{newValue(name), append(aka, name)}
 }
 
 func NewString[T ~string](v T) *String[T] {
-	return &String[T]{v: v}
+	return &String[T]{v: newValue(v)}
+}
+
+func (opt *String[T]) box() *box { return opt.v.box() }
+
+func (opt *String[T]) cell() *value[T] { return &opt.v }
+
+func (opt *String[T]) check(v T) error {
+	if len(opt.aka) == 0 {
+		return nil
+	}
+	for _, s := range opt.aka {
+		if s == v {
+			return nil
+		}
+	}
+	return fmt.Errorf("%q invalid", v)
+}
+
+func (opt *String[T]) Default() T {
+	return opt.v.state().layers[Default]
+}
+
+func (opt *String[T]) IsDefault() bool {
+	return opt.v.state().top == Default
 }
 
 func (opt String[T]) MarshalJSON() ([]byte, error) {
@@ -38,28 +62,40 @@ func (opt String[T]) MarshalYAML() (interface{}, error) {
 	return opt.Value(), nil
 }
 
+func (opt *String[T]) parse(s string) (T, error) { return T(s), nil }
+
+func (opt *String[T]) reset() write { return reset[T](opt) }
+
+func (opt *String[T]) Reset() {
+	commit(opt.reset())
+}
+
+func (opt *String[T]) schema() map[string]any {
+	s := map[string]any{"type": "string"}
+	if len(opt.aka) > 0 {
+		s["enum"] = opt.aka
+	}
+	return s
+}
+
 func (opt *String[T]) Set(s string) error {
 	return opt.Store(T(s))
 }
 
-func (opt *String[T]) Store(v T) error {
-	if len(opt.aka) == 0 {
-		return opt.store(v)
-	}
-	for _, s := range opt.aka {
-		if s == v {
-			return opt.store(v)
-		}
-	}
-	return fmt.Errorf("%q invalid", v)
+func (opt *String[T]) settings() []Setting {
+	return opt.v.state().settings(opt)
 }
 
-func (opt *String[T]) store(v T) error {
-	mutex.Lock()
-	defer mutex.Unlock()
-	opt.v = v
-	publish(uintptr(unsafe.Pointer(opt)))
-	return nil
+func (opt *String[T]) Source() Source {
+	return opt.v.state().source()
+}
+
+func (opt *String[T]) stage(src Source, v any) (write, error) {
+	return stage[T](opt, src, v)
+}
+
+func (opt *String[T]) Store(v T) error {
+	return store[T](opt, v)
 }
 
 func (opt String[T]) String() string {
@@ -78,23 +114,47 @@ func (opt *String[T]) UnmarshalText(text []byte) error {
 	return opt.Set(string(text))
 }
 
+func (opt *String[T]) Unset(l Layer) error {
+	return apply(opt, Source{Layer: l}, nil)
+}
+
+func (opt *String[T]) Validate(fns ...func(T) error) *String[T] {
+	opt.v.validate(fns)
+	return opt
+}
+
 func (opt String[T]) Value() T {
-	mutex.RLock()
-	defer mutex.RUnlock()
-	return opt.v
+	return opt.v.load()
 }
 
-type Strings[T ~string] struct{ v []T }
+func (opt *String[T]) Watch(ctx context.Context) <-chan T {
+	return watch(ctx, opt, opt.Value)
+}
+
+type Strings[T ~string] struct{ v value[[]T] }
 
 func NewStrings[T ~string](v []T) *Strings[T] {
-	return &Strings[T]{v}
+	return &Strings[T]{newValue(v)}
+}
+
+func (opt *Strings[T]) box() *box { return opt.v.box() }
+
+func (opt *Strings[T]) cell() *value[[]T] { return &opt.v }
+
+func (opt *Strings[T]) check([]T) error { return nil }
+
+func (opt *Strings[T]) Default() []T {
+	return opt.v.state().layers[Default]
+}
+
+func (opt *Strings[T]) IsDefault() bool {
+	return opt.v.state().top == Default
 }
 
 func (opt Strings[T]) MarshalJSON() ([]byte, error) {
-	mutex.RLock()
-	defer mutex.RUnlock()
-	ses := make([]string, len(opt.v))
-	for i, v := range opt.v {
+	vs := opt.Value()
+	ses := make([]string, len(vs))
+	for i, v := range vs {
 		ses[i] = string(v)
 	}
 	return json.Marshal(ses)
@@ -108,28 +168,64 @@ func (opt Strings[T]) MarshalYAML() (interface{}, error) {
 	return opt.Value(), nil
 }
 
+func (opt *Strings[T]) parse(s string) ([]T, error) {
+	l, err := list(s)
+	if err != nil {
+		return nil, err
+	}
+	v := make([]T, len(l))
+	for i, s := range l {
+		v[i] = T(s)
+	}
+	return v, nil
+}
+
+func (opt *Strings[T]) reset() write { return reset[[]T](opt) }
+
+func (opt *Strings[T]) Reset() {
+	commit(opt.reset())
+}
+
+func (opt *Strings[T]) schema() map[string]any {
+	return map[string]any{
+		"type":  "array",
+		"items": map[string]any{"type": "string"},
+	}
+}
+
+func (opt *Strings[T]) Set(s string) error {
+	v, err := opt.parse(s)
+	if err != nil {
+		return err
+	}
+	return opt.Store(v)
+}
+
+func (opt *Strings[T]) settings() []Setting {
+	return opt.v.state().settings(opt)
+}
+
+func (opt *Strings[T]) Source() Source {
+	return opt.v.state().source()
+}
+
+func (opt *Strings[T]) stage(src Source, v any) (write, error) {
+	return stage[[]T](opt, src, v)
+}
+
 func (opt *Strings[T]) Store(v []T) error {
-	mutex.Lock()
-	defer mutex.Unlock()
-	opt.v = v
-	publish(uintptr(unsafe.Pointer(opt)))
-	return nil
+	return store[[]T](opt, v)
 }
 
 func (opt Strings[T]) String() string {
+	v := opt.Value()
 	sb := new(strings.Builder)
-	mutex.RLock()
-	defer mutex.RUnlock()
 	fmt.Fprint(sb, "[")
-	for i, s := range opt.v {
+	for i, s := range v {
 		if i > 0 {
 			fmt.Fprint(sb, " ")
 		}
-		if strings.Contains(string(s), " \t") {
-			fmt.Fprintf(sb, "%q", s)
-		} else {
-			fmt.Fprint(sb, s)
-		}
+		fmt.Fprint(sb, quote(string(s)))
 	}
 	fmt.Fprint(sb, "]")
 	return sb.String()
@@ -159,8 +255,19 @@ func (opt *Strings[T]) UnmarshalYAML(unmarshal func(interface{}) error) error {
 	return opt.Store(v)
 }
 
+func (opt *Strings[T]) Unset(l Layer) error {
+	return apply(opt, Source{Layer: l}, nil)
+}
+
+func (opt *Strings[T]) Validate(fns ...func([]T) error) *Strings[T] {
+	opt.v.validate(fns)
+	return opt
+}
+
 func (opt Strings[T]) Value() []T {
-	mutex.RLock()
-	defer mutex.RUnlock()
-	return opt.v
+	return opt.v.load()
+}
+
+func (opt *Strings[T]) Watch(ctx context.Context) <-chan []T {
+	return watch(ctx, opt, opt.Value)
 }
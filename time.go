@@ -5,16 +5,19 @@
 package opt
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"time"
-	"unsafe"
 )
 
-type Time struct{ v, min, max time.Time }
+type Time struct {
+	v        value[time.Time]
+	min, max time.Time
+}
 
 func LimitedTime(v, min, max time.Time) *Time {
-	return &Time{v, min, max}
+	return &Time{newValue(v), min, max}
 }
 
 func MustParseTime(s string) *Time {
@@ -40,7 +43,31 @@ func MustParseLimitedTime(sv, smin, smax string) *Time {
 }
 
 func NewTime(v time.Time) *Time {
-	return &Time{v: v}
+	return &Time{v: newValue(v)}
+}
+
+func (opt *Time) box() *box { return opt.v.box() }
+
+func (opt *Time) cell() *value[time.Time] { return &opt.v }
+
+func (opt *Time) check(v time.Time) error {
+	if !opt.min.Equal(opt.max) {
+		if v.Before(opt.min) {
+			return fmt.Errorf("too soon")
+		}
+		if v.After(opt.max) {
+			return fmt.Errorf("too late")
+		}
+	}
+	return nil
+}
+
+func (opt *Time) Default() time.Time {
+	return opt.v.state().layers[Default]
+}
+
+func (opt *Time) IsDefault() bool {
+	return opt.v.state().top == Default
 }
 
 func (opt Time) MarshalJSON() ([]byte, error) {
@@ -48,9 +75,8 @@ func (opt Time) MarshalJSON() ([]byte, error) {
 }
 
 func (opt Time) MarshalText() ([]byte, error) {
-	mutex.RLock()
-	defer mutex.RUnlock()
-	return opt.v.MarshalText()
+	v := opt.Value()
+	return v.MarshalText()
 }
 
 func (opt Time) MarshalYAML() (interface{}, error) {
@@ -58,24 +84,40 @@ func (opt Time) MarshalYAML() (interface{}, error) {
 	return string(text), err
 }
 
+func (opt *Time) parse(s string) (time.Time, error) {
+	var v time.Time
+	err := v.UnmarshalText([]byte(s))
+	return v, err
+}
+
+func (opt *Time) reset() write { return reset[time.Time](opt) }
+
+func (opt *Time) Reset() {
+	commit(opt.reset())
+}
+
+func (opt *Time) schema() map[string]any {
+	return map[string]any{"type": "string", "format": "date-time"}
+}
+
 func (opt *Time) Set(s string) error {
 	return opt.UnmarshalText([]byte(s))
 }
 
+func (opt *Time) settings() []Setting {
+	return opt.v.state().settings(opt)
+}
+
+func (opt *Time) Source() Source {
+	return opt.v.state().source()
+}
+
+func (opt *Time) stage(src Source, v any) (write, error) {
+	return stage[time.Time](opt, src, v)
+}
+
 func (opt *Time) Store(v time.Time) error {
-	if !opt.min.Equal(opt.max) {
-		if v.Before(opt.min) {
-			return fmt.Errorf("too soon")
-		}
-		if v.After(opt.max) {
-			return fmt.Errorf("too late")
-		}
-	}
-	mutex.Lock()
-	defer mutex.Unlock()
-	opt.v = v
-	publish(uintptr(unsafe.Pointer(opt)))
-	return nil
+	return store[time.Time](opt, v)
 }
 
 func (opt Time) String() string {
@@ -95,15 +137,26 @@ func (opt *Time) UnmarshalJSON(text []byte) error {
 }
 
 func (opt *Time) UnmarshalText(text []byte) error {
-	var v time.Time
-	if err := v.UnmarshalText(text); err != nil {
+	v, err := opt.parse(string(text))
+	if err != nil {
 		return err
 	}
 	return opt.Store(v)
 }
 
+func (opt *Time) Unset(l Layer) error {
+	return apply(opt, Source{Layer: l}, nil)
+}
+
+func (opt *Time) Validate(fns ...func(time.Time) error) *Time {
+	opt.v.validate(fns)
+	return opt
+}
+
 func (opt Time) Value() time.Time {
-	mutex.RLock()
-	defer mutex.RUnlock()
-	return opt.v
+	return opt.v.load()
+}
+
+func (opt *Time) Watch(ctx context.Context) <-chan time.Time {
+	return watch(ctx, opt, opt.Value)
 }
@@ -0,0 +1,87 @@
+// Copyright © 2021-2022 Platina Systems, Inc. All rights reserved.
+// Use of this source code is governed by the GPL-2 license described in the
+// LICENSE file.
+
+package opt
+
+import "fmt"
+
+// Tx stages stores to many options that are checked as they're staged then
+// committed together, or not at all, with one change notification whose
+// Group lists each option. The zero value is an empty transaction.
+type Tx struct {
+	writes []write
+	err    error
+}
+
+// Commit the staged stores unless any failed, then reset the transaction.
+func (tx *Tx) Commit() error {
+	writes, err := tx.writes, tx.err
+	tx.Discard()
+	if err != nil {
+		return err
+	}
+	commit(writes...)
+	return nil
+}
+
+// Discard the staged stores.
+func (tx *Tx) Discard() {
+	tx.writes = nil
+	tx.err = nil
+}
+
+// Reset stages the removal of every layer of opt above Default.
+func (tx *Tx) Reset(opt Option) error {
+	st, ok := opt.(stager)
+	if !ok {
+		return tx.fail(fmt.Errorf("%T invalid", opt))
+	}
+	tx.writes = append(tx.writes, st.reset())
+	return nil
+}
+
+// Set stages a new Runtime value of opt from its text form.
+func (tx *Tx) Set(opt Option, s string) error {
+	return tx.Store(opt, s)
+}
+
+// Store stages a new Runtime value of opt, either typed, e.g.
+// time.Duration, or its text form.
+func (tx *Tx) Store(opt Option, v any) error {
+	return tx.stage(opt, Source{Layer: Runtime}, v)
+}
+
+// StoreSource stages a new value of opt, either typed or its text form, in
+// the layer of the given source.
+func (tx *Tx) StoreSource(opt Option, src Source, v any) error {
+	return tx.stage(opt, src, v)
+}
+
+// Unset stages the removal of the given layer of opt.
+func (tx *Tx) Unset(opt Option, l Layer) error {
+	return tx.stage(opt, Source{Layer: l}, nil)
+}
+
+func (tx *Tx) stage(opt Option, src Source, v any) error {
+	st, ok := opt.(stager)
+	if !ok {
+		return tx.fail(fmt.Errorf("%T invalid", opt))
+	}
+	w, err := st.stage(src, v)
+	if err != nil {
+		if name := nameof(opt); len(name) > 0 {
+			err = fmt.Errorf("%s: %w", name, err)
+		}
+		return tx.fail(err)
+	}
+	tx.writes = append(tx.writes, w)
+	return nil
+}
+
+func (tx *Tx) fail(err error) error {
+	if tx.err == nil {
+		tx.err = err
+	}
+	return err
+}
@@ -5,20 +5,34 @@
 package opt
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/url"
-	"unsafe"
 )
 
-type URL struct{ v url.URL }
+type URL struct{ v value[url.URL] }
 
 func MustParseURL(s string) *URL {
 	p, err := url.Parse(s)
 	if err != nil {
 		panic(err)
 	}
-	return &URL{*p}
+	return &URL{newValue(*p)}
+}
+
+func (opt *URL) box() *box { return opt.v.box() }
+
+func (opt *URL) cell() *value[url.URL] { return &opt.v }
+
+func (opt *URL) check(url.URL) error { return nil }
+
+func (opt *URL) Default() url.URL {
+	return opt.v.state().layers[Default]
+}
+
+func (opt *URL) IsDefault() bool {
+	return opt.v.state().top == Default
 }
 
 func (opt URL) MarshalJSON() ([]byte, error) {
@@ -33,22 +47,51 @@ func (opt URL) MarshalYAML() (interface{}, error) {
 	return opt.String(), nil
 }
 
-func (opt *URL) Set(s string) error {
+func (opt *URL) parse(s string) (url.URL, error) {
 	p, err := url.Parse(s)
+	if err != nil {
+		return url.URL{}, err
+	}
+	return *p, nil
+}
+
+func (opt *URL) reset() write { return reset[url.URL](opt) }
+
+func (opt *URL) Reset() {
+	commit(opt.reset())
+}
+
+func (opt *URL) schema() map[string]any {
+	return map[string]any{"type": "string", "format": "uri"}
+}
+
+func (opt *URL) Set(s string) error {
+	v, err := opt.parse(s)
 	if err != nil {
 		return err
 	}
-	mutex.Lock()
-	defer mutex.Unlock()
-	opt.v = *p
-	publish(uintptr(unsafe.Pointer(opt)))
-	return nil
+	return opt.Store(v)
+}
+
+func (opt *URL) settings() []Setting {
+	return opt.v.state().settings(opt)
+}
+
+func (opt *URL) Source() Source {
+	return opt.v.state().source()
+}
+
+func (opt *URL) stage(src Source, v any) (write, error) {
+	return stage[url.URL](opt, src, v)
+}
+
+func (opt *URL) Store(v url.URL) error {
+	return store[url.URL](opt, v)
 }
 
 func (opt URL) String() string {
-	mutex.RLock()
-	defer mutex.RUnlock()
-	return opt.v.String()
+	v := opt.Value()
+	return v.String()
 }
 
 func (opt *URL) UnmarshalJSON(text []byte) error {
@@ -66,8 +109,19 @@ func (opt *URL) UnmarshalText(text []byte) error {
 	return opt.Set(string(text))
 }
 
+func (opt *URL) Unset(l Layer) error {
+	return apply(opt, Source{Layer: l}, nil)
+}
+
+func (opt *URL) Validate(fns ...func(url.URL) error) *URL {
+	opt.v.validate(fns)
+	return opt
+}
+
 func (opt URL) Value() url.URL {
-	mutex.RLock()
-	defer mutex.RUnlock()
-	return opt.v
+	return opt.v.load()
+}
+
+func (opt *URL) Watch(ctx context.Context) <-chan url.URL {
+	return watch(ctx, opt, opt.Value)
 }
@@ -0,0 +1,223 @@
+// Copyright © 2021-2022 Platina Systems, Inc. All rights reserved.
+// Use of this source code is governed by the GPL-2 license described in the
+// LICENSE file.
+
+package opt
+
+import (
+	"fmt"
+	"sync/atomic"
+	"time"
+	"unsafe"
+)
+
+// value is an option's own storage of its layered values and validators,
+// read without locks by atomic load of a pointer to an immutable state; the
+// zero value has the zero T as its default. The state pointer is in a box
+// that copies of the option share, so that value receivers, e.g. of String
+// and MarshalYAML, load it atomically too; that of a zero value is allocated
+// by its first use, e.g. when it's registered.
+type value[T any] struct{ b unsafe.Pointer }
+
+// box has the pointer to an option's state and to its registered name.
+type box struct {
+	p    unsafe.Pointer // *state[T]
+	name unsafe.Pointer // *string
+}
+
+type state[T any] struct {
+	v      T // of the top layer
+	top    Layer
+	set    uint8 // bit mask of layers above Default with a value
+	layers [nLayers]T
+	srcs   [nLayers]Source
+	fns    []func(T) error
+}
+
+func newValue[T any](v T) value[T] {
+	st := &state[T]{v: v}
+	st.layers[Default] = v
+	return value[T]{unsafe.Pointer(&box{p: unsafe.Pointer(st)})}
+}
+
+// box returns the option's box, allocating that of a zero value.
+func (val *value[T]) box() *box {
+	if b := atomic.LoadPointer(&val.b); b != nil {
+		return (*box)(b)
+	}
+	atomic.CompareAndSwapPointer(&val.b, nil, unsafe.Pointer(new(box)))
+	return (*box)(atomic.LoadPointer(&val.b))
+}
+
+func (val *value[T]) load() T {
+	return val.state().v
+}
+
+func (val *value[T]) state() *state[T] {
+	if p := atomic.LoadPointer(&val.box().p); p != nil {
+		return (*state[T])(p)
+	}
+	return new(state[T])
+}
+
+// settings returns the text value, formatted like opt, and source of each
+// layer that's set, lowest first.
+func (st *state[T]) settings(opt Option) []Setting {
+	var settings []Setting
+	for l := Default; l < nLayers; l++ {
+		if l == Default || st.set&(1<<l) != 0 {
+			src := st.srcs[l]
+			src.Layer = l
+			settings = append(settings, Setting{
+				Value:  format(opt, st.layers[l]),
+				Source: src,
+			})
+		}
+	}
+	return settings
+}
+
+func (st *state[T]) source() Source {
+	src := st.srcs[st.top]
+	src.Layer = st.top
+	return src
+}
+
+// reset removes every layer above Default.
+func (val *value[T]) reset() (old, new T, changed bool) {
+	st := *val.state()
+	if st.set == 0 {
+		return st.v, st.v, false
+	}
+	old = st.v
+	def, src := st.layers[Default], st.srcs[Default]
+	st.layers, st.srcs = [nLayers]T{Default: def}, [nLayers]Source{Default: src}
+	st.set, st.top, st.v = 0, Default, def
+	atomic.StorePointer(&val.box().p, unsafe.Pointer(&st))
+	return old, st.v, true
+}
+
+// swap in a new value of the source's layer, or if nil, remove that layer.
+// swap returns the old and new values of the top layer and whether it
+// changed. The caller must hold the mutex that serializes writers.
+func (val *value[T]) swap(src Source, v *T) (old, new T, changed bool) {
+	st := *val.state()
+	old = st.v
+	l := src.Layer
+	if v != nil {
+		st.layers[l] = *v
+		st.srcs[l] = src
+		if l > Default {
+			st.set |= 1 << l
+		}
+	} else if st.set&(1<<l) != 0 {
+		var zero T
+		st.layers[l] = zero
+		st.srcs[l] = Source{}
+		st.set &^= 1 << l
+	} else {
+		return old, old, false
+	}
+	changed = l >= st.top
+	for st.top = Runtime; st.top > Default; st.top-- {
+		if st.set&(1<<st.top) != 0 {
+			break
+		}
+	}
+	st.v = st.layers[st.top]
+	atomic.StorePointer(&val.box().p, unsafe.Pointer(&st))
+	return old, st.v, changed
+}
+
+// validate appends validators that must accept each new value.
+func (val *value[T]) validate(fns []func(T) error) {
+	mutex.Lock()
+	defer mutex.Unlock()
+	st := *val.state()
+	st.fns = append(st.fns[:len(st.fns):len(st.fns)], fns...)
+	atomic.StorePointer(&val.box().p, unsafe.Pointer(&st))
+}
+
+// typed is implemented by pointers to the option types of T.
+type typed[T any] interface {
+	stager
+	cell() *value[T]
+	check(T) error
+	parse(string) (T, error)
+}
+
+// stager is the untyped view of a typed option.
+type stager interface {
+	Option
+	box() *box
+	IsDefault() bool
+	Source() Source
+	reset() write
+	settings() []Setting
+	stage(Source, any) (write, error)
+}
+
+// write is a checked store of an option that is yet to be committed.
+type write struct {
+	opt  stager
+	swap func(time.Time) (old, new any, changed bool)
+}
+
+// reset the option to its Default layer.
+func reset[T any](opt typed[T]) write {
+	return write{opt, func(time.Time) (any, any, bool) {
+		return opt.cell().reset()
+	}}
+}
+
+// stage a store of v, either a T or its text form, in the source's layer;
+// or if v is nil, the removal of that layer.
+func stage[T any](opt typed[T], src Source, v any) (write, error) {
+	l := src.Layer
+	if l >= nLayers {
+		return write{}, fmt.Errorf("%v invalid", l)
+	}
+	if v == nil {
+		if l == Default {
+			return write{}, fmt.Errorf("can't unset %v", l)
+		}
+		return write{opt, func(time.Time) (any, any, bool) {
+			return opt.cell().swap(src, nil)
+		}}, nil
+	}
+	t, ok := v.(T)
+	if !ok {
+		s, ok := v.(string)
+		if !ok {
+			return write{}, fmt.Errorf("%T invalid", v)
+		}
+		var err error
+		if t, err = opt.parse(s); err != nil {
+			return write{}, err
+		}
+	}
+	if err := opt.check(t); err != nil {
+		return write{}, err
+	}
+	for _, fn := range opt.cell().state().fns {
+		if err := fn(t); err != nil {
+			return write{}, err
+		}
+	}
+	return write{opt, func(now time.Time) (any, any, bool) {
+		if src.Time.IsZero() {
+			src.Time = now
+		}
+		return opt.cell().swap(src, &t)
+	}}, nil
+}
+
+// store v in the Runtime layer.
+func store[T any](opt typed[T], v T) error {
+	w, err := stage[T](opt, Source{Layer: Runtime}, v)
+	if err != nil {
+		return err
+	}
+	commit(w)
+	return nil
+}
@@ -0,0 +1,30 @@
+// Copyright © 2021-2022 Platina Systems, Inc. All rights reserved.
+// Use of this source code is governed by the GPL-2 license described in the
+// LICENSE file.
+
+package opt
+
+import (
+	"runtime"
+	"sync/atomic"
+)
+
+// version is odd while a writer is swapping option values.
+var version uint64
+
+// View calls fn, again if necessary, until every option value that it reads
+// is from the same version of all options, i.e. no write was committed
+// while fn ran. Since it may be repeated, fn should only read options and
+// assign its own variables. View returns the version that fn read.
+func View(fn func()) uint64 {
+	for {
+		v := atomic.LoadUint64(&version)
+		if v&1 == 0 {
+			fn()
+			if atomic.LoadUint64(&version) == v {
+				return v / 2
+			}
+		}
+		runtime.Gosched()
+	}
+}
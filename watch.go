@@ -0,0 +1,37 @@
+// Copyright © 2021-2022 Platina Systems, Inc. All rights reserved.
+// Use of this source code is governed by the GPL-2 license described in the
+// LICENSE file.
+
+package opt
+
+import "context"
+
+// watch returns a channel that receives the current value of opt and then
+// its latest value after each change, skipping those replaced before they
+// were received. The channel is closed once ctx is done.
+func watch[T any](ctx context.Context, opt Option, load func() T) <-chan T {
+	out := make(chan T)
+	changes := make(chan Change)
+	subscribe(changes, Coalesce, opt)
+	v := load()
+	go func() {
+		defer close(out)
+		defer Unsubscribe(changes)
+		pending := true
+		for {
+			send := out
+			if !pending {
+				send = nil
+			}
+			select {
+			case send <- v:
+				pending = false
+			case c := <-changes:
+				v, pending = c.New.(T), true
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return out
+}